@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"debug/buildinfo"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sort"
+	"strings"
+)
+
+/************************************
+* Function Name: goModuleDep
+* Purpose: Format a module recorded in Go build info as a dependency entry,
+*          with its go.sum hash and any replacement.
+* Parameters: m *debug.Module, notes ...string
+* Output: string (format: path@version (h1: x, replace: path@version | path: dir))
+*************************************/
+func goModuleDep(m *debug.Module, notes ...string) string {
+	dep := m.Path
+	// "(devel)" marks a main module built from a working tree
+	if m.Version != "" && m.Version != "(devel)" {
+		dep = fmt.Sprintf("%s@%s", m.Path, m.Version)
+	}
+	sum := m.Sum
+	if r := m.Replace; r != nil {
+		if r.Version == "" {
+			notes = append(notes, "path: "+r.Path)
+		} else {
+			notes = append(notes, fmt.Sprintf("replace: %s@%s", r.Path, r.Version))
+		}
+		sum = r.Sum
+	}
+	if sum != "" {
+		notes = append(notes, strings.Replace(sum, ":", ": ", 1))
+	}
+	return annotateDep(dep, notes...)
+}
+
+/************************************
+* Function Name: parseGoBinaryDeps
+* Purpose: Read the build info embedded in a Go executable (ELF, Mach-O or PE)
+*          and report the main module, every dependency module and the Go
+*          toolchain that built it.
+* Parameters: path string
+* Output: []string (format: module@version (main | h1: x) and stdlib@version (toolchain)), error
+*************************************/
+func parseGoBinaryDeps(path string) ([]string, error) {
+	f, err := sourceFS.Open(filepath.ToSlash(path))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	// host files are read in place; other sources are loaded when they cannot seek
+	r, ok := f.(io.ReaderAt)
+	if !ok {
+		b, err := io.ReadAll(f)
+		if err != nil {
+			return nil, err
+		}
+		r = bytes.NewReader(b)
+	}
+	info, err := buildinfo.Read(r)
+	if err != nil {
+		return nil, err
+	}
+	deps := map[string]struct{}{}
+
+	settings := map[string]string{}
+	for _, s := range info.Settings {
+		settings[s.Key] = s.Value
+	}
+	mainNotes := []string{"main"}
+	if rev := settings["vcs.revision"]; rev != "" {
+		mainNotes = append(mainNotes, "revision: "+rev)
+	}
+	if settings["GOOS"] != "" && settings["GOARCH"] != "" {
+		mainNotes = append(mainNotes, "platform: "+settings["GOOS"]+"-"+settings["GOARCH"])
+	}
+	if info.Main.Path != "" {
+		deps[goModuleDep(&info.Main, mainNotes...)] = struct{}{}
+	}
+	for _, m := range info.Deps {
+		deps[goModuleDep(m)] = struct{}{}
+	}
+	if v := strings.TrimPrefix(info.GoVersion, "go"); v != "" {
+		deps[annotateDep("stdlib@"+v, "toolchain")] = struct{}{}
+	}
+	return setToSortedSlice(deps), nil
+}
+
+/************************************
+* Function Name: analyzeBinaries
+* Purpose: Build an Analysis for a Go executable, or for every Go executable
+*          found under a directory. Files that are not Go binaries are skipped.
+* Parameters: path string
+* Output: Analysis, error
+*************************************/
+func analyzeBinaries(path string) (Analysis, error) {
+	var a Analysis
+	a.Repo = filepath.Base(path)
+	a.Dependencies = map[string]map[string][]string{}
+
+	st, err := fs.Stat(sourceFS, filepath.ToSlash(path))
+	if err != nil {
+		return a, err
+	}
+	root := filepath.Dir(path)
+	var files []string
+	if st.IsDir() {
+		root = path
+		walkSource(path, func(p string, info os.FileInfo, err error) error {
+			if err == nil && info.Mode().IsRegular() {
+				files = append(files, p)
+			}
+			return nil
+		})
+	} else {
+		files = []string{path}
+	}
+
+	perFile := map[string][]string{}
+	for _, f := range files {
+		deps, err := parseGoBinaryDeps(f)
+		if err != nil {
+			if !st.IsDir() {
+				return a, fmt.Errorf("%s: %v", f, err)
+			}
+			continue
+		}
+		rel, err := filepath.Rel(root, f)
+		if err != nil {
+			rel = f
+		}
+		perFile[rel] = deps
+		a.Files = append(a.Files, rel)
+	}
+	if len(perFile) > 0 {
+		a.Type = []string{"Go"}
+		a.Dependencies["Go"] = perFile
+	}
+	sort.Strings(a.Files)
+	a.Purls = collectPurls(a.Dependencies)
+	a.fileSHA1 = fileChecksums(root, a.Files)
+	return a, nil
+}
@@ -0,0 +1,490 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// cyclonedxVersion is the CycloneDX specification version written by the
+// cyclonedx-json and cyclonedx-xml outputs (-cyclonedx-version).
+var cyclonedxVersion = "1.6"
+
+// cdxBOM is a CycloneDX document; the same structure is marshalled to JSON and XML.
+type cdxBOM struct {
+	XMLName      xml.Name        `json:"-" xml:"bom"`
+	XMLNS        string          `json:"-" xml:"xmlns,attr"`
+	BOMFormat    string          `json:"bomFormat" xml:"-"`
+	SpecVersion  string          `json:"specVersion" xml:"-"`
+	SerialNumber string          `json:"serialNumber" xml:"serialNumber,attr"`
+	Version      int             `json:"version" xml:"version,attr"`
+	Metadata     cdxMetadata     `json:"metadata" xml:"metadata"`
+	Components   []cdxComponent  `json:"components" xml:"components>component"`
+	Dependencies []cdxDependency `json:"dependencies" xml:"dependencies>dependency"`
+}
+
+type cdxMetadata struct {
+	Timestamp string        `json:"timestamp" xml:"timestamp"`
+	Tools     cdxTools      `json:"tools" xml:"tools"`
+	Component *cdxComponent `json:"component,omitempty" xml:"component,omitempty"`
+}
+
+type cdxTools struct {
+	Components []cdxComponent `json:"components" xml:"components>component"`
+}
+
+type cdxComponent struct {
+	Type               string                `json:"type" xml:"type,attr"`
+	BOMRef             string                `json:"bom-ref,omitempty" xml:"bom-ref,attr,omitempty"`
+	Name               string                `json:"name" xml:"name"`
+	Version            string                `json:"version,omitempty" xml:"version,omitempty"`
+	Scope              string                `json:"scope,omitempty" xml:"scope,omitempty"`
+	Hashes             cdxHashes             `json:"hashes,omitempty" xml:"hashes,omitempty"`
+	Licenses           cdxLicenses           `json:"licenses,omitempty" xml:"licenses,omitempty"`
+	PURL               string                `json:"purl,omitempty" xml:"purl,omitempty"`
+	ExternalReferences cdxExternalReferences `json:"externalReferences,omitempty" xml:"externalReferences,omitempty"`
+	Properties         cdxProperties         `json:"properties,omitempty" xml:"properties,omitempty"`
+}
+
+type cdxHash struct {
+	Alg     string `json:"alg" xml:"alg,attr"`
+	Content string `json:"content" xml:",chardata"`
+}
+
+type cdxHashes []cdxHash
+
+type cdxLicense struct {
+	ID   string `json:"id,omitempty" xml:"id,omitempty"`
+	Name string `json:"name,omitempty" xml:"name,omitempty"`
+}
+
+// cdxLicenseChoice is either a single license or an SPDX expression.
+type cdxLicenseChoice struct {
+	License    *cdxLicense `json:"license,omitempty"`
+	Expression string      `json:"expression,omitempty"`
+}
+
+type cdxLicenses []cdxLicenseChoice
+
+type cdxExternalReference struct {
+	Type string `json:"type" xml:"type,attr"`
+	URL  string `json:"url" xml:"url"`
+}
+
+type cdxExternalReferences []cdxExternalReference
+
+type cdxProperty struct {
+	Name  string `json:"name" xml:"name,attr"`
+	Value string `json:"value" xml:",chardata"`
+}
+
+type cdxProperties []cdxProperty
+
+type cdxDependency struct {
+	Ref       string   `json:"ref"`
+	DependsOn []string `json:"dependsOn"`
+}
+
+// The list types below wrap their items in a container element. Marshalling
+// them as a type (rather than with an "a>b" tag) lets omitempty drop the
+// container when the list is empty.
+
+func (h cdxHashes) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return e.EncodeElement(struct {
+		Items []cdxHash `xml:"hash"`
+	}{h}, start)
+}
+
+func (r cdxExternalReferences) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return e.EncodeElement(struct {
+		Items []cdxExternalReference `xml:"reference"`
+	}{r}, start)
+}
+
+func (p cdxProperties) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return e.EncodeElement(struct {
+		Items []cdxProperty `xml:"property"`
+	}{p}, start)
+}
+
+/************************************
+* Function Name: MarshalXML (cdxLicenses)
+* Purpose: Write <licenses> with <license> or <expression> children; the XML
+*          schema has no per-choice wrapper element.
+* Parameters: e *xml.Encoder, start xml.StartElement
+* Output: error
+*************************************/
+func (l cdxLicenses) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	for _, c := range l {
+		var err error
+		if c.License != nil {
+			err = e.EncodeElement(c.License, xml.StartElement{Name: xml.Name{Local: "license"}})
+		} else {
+			err = e.EncodeElement(c.Expression, xml.StartElement{Name: xml.Name{Local: "expression"}})
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return e.EncodeToken(start.End())
+}
+
+/************************************
+* Function Name: MarshalXML (cdxDependency)
+* Purpose: Write <dependency ref="..."> with one nested <dependency ref="..."/>
+*          per dependsOn entry, as the XML schema expects.
+* Parameters: e *xml.Encoder, start xml.StartElement
+* Output: error
+*************************************/
+func (d cdxDependency) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	ref := func(v string) []xml.Attr { return []xml.Attr{{Name: xml.Name{Local: "ref"}, Value: v}} }
+	start.Attr = ref(d.Ref)
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	for _, r := range d.DependsOn {
+		child := xml.StartElement{Name: xml.Name{Local: "dependency"}, Attr: ref(r)}
+		if err := e.EncodeToken(child); err != nil {
+			return err
+		}
+		if err := e.EncodeToken(child.End()); err != nil {
+			return err
+		}
+	}
+	return e.EncodeToken(start.End())
+}
+
+// cyclonedxHashAlgs maps hash notes to CycloneDX hash algorithm names.
+var cyclonedxHashAlgs = map[string]string{
+	"md5":    "MD5",
+	"sha1":   "SHA-1",
+	"sha256": "SHA-256",
+	"sha384": "SHA-384",
+	"sha512": "SHA-512",
+}
+
+var hexDigestRe = regexp.MustCompile(`^[0-9a-fA-F]{32,128}$`)
+
+// spdxLicenseIDs are common SPDX identifiers written as license ids; other
+// single licenses are written as names so the document stays schema-valid.
+var spdxLicenseIDs = map[string]bool{
+	"0BSD": true, "AGPL-3.0-only": true, "AGPL-3.0-or-later": true, "Apache-2.0": true,
+	"Artistic-2.0": true, "BSD-2-Clause": true, "BSD-3-Clause": true, "BSL-1.0": true,
+	"CC0-1.0": true, "EPL-1.0": true, "EPL-2.0": true, "GPL-2.0-only": true,
+	"GPL-2.0-or-later": true, "GPL-3.0-only": true, "GPL-3.0-or-later": true, "ISC": true,
+	"LGPL-2.1-only": true, "LGPL-2.1-or-later": true, "LGPL-3.0-only": true,
+	"LGPL-3.0-or-later": true, "MIT": true, "MPL-2.0": true, "PSF-2.0": true,
+	"Python-2.0": true, "Ruby": true, "Unlicense": true, "Zlib": true,
+}
+
+/************************************
+* Function Name: cyclonedxLicenses
+* Purpose: Turn a license note into CycloneDX license choices: SPDX
+*          expressions (AND / OR / WITH) as an expression, known SPDX ids as
+*          an id and anything else as a license name.
+* Parameters: license string
+* Output: cdxLicenses
+*************************************/
+func cyclonedxLicenses(license string) cdxLicenses {
+	switch {
+	case license == "":
+		return nil
+	case strings.Contains(license, " OR ") || strings.Contains(license, " AND ") || strings.Contains(license, " WITH "):
+		return cdxLicenses{{Expression: license}}
+	case spdxLicenseIDs[license]:
+		return cdxLicenses{{License: &cdxLicense{ID: license}}}
+	}
+	return cdxLicenses{{License: &cdxLicense{Name: license}}}
+}
+
+/************************************
+* Function Name: cyclonedxScope
+* Purpose: Map dependency notes to a CycloneDX scope: development, test and
+*          build-time dependencies are excluded from the runtime, optional and
+*          peer dependencies are optional, everything else is required.
+* Parameters: notes []string
+* Output: string
+*************************************/
+func cyclonedxScope(notes []string) string {
+	scope, _ := depNote(notes, "scope")
+	switch {
+	case hasNote(notes, "dev") || hasNote(notes, "test") || hasNote(notes, "build") || strings.Contains(strings.ToLower(scope), "test"):
+		return "excluded"
+	case hasNote(notes, "optional") || hasNote(notes, "peer"):
+		return "optional"
+	}
+	return "required"
+}
+
+// cyclonedxScopeRank orders scopes so a component used in several places keeps the strongest one.
+var cyclonedxScopeRank = map[string]int{"excluded": 0, "optional": 1, "required": 2}
+
+/************************************
+* Function Name: newUUID
+* Purpose: Generate a random (version 4) UUID, used for the BOM serial
+*          number and SPDX document namespaces.
+* Parameters: none
+* Output: string
+*************************************/
+func newUUID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+/************************************
+* Function Name: addProperty
+* Purpose: Add a name/value property to a component unless it is already set.
+* Parameters: c *cdxComponent, name string, value string
+* Output: none
+*************************************/
+func addProperty(c *cdxComponent, name, value string) {
+	for _, p := range c.Properties {
+		if p.Name == name && p.Value == value {
+			return
+		}
+	}
+	c.Properties = append(c.Properties, cdxProperty{Name: name, Value: value})
+}
+
+// resolvedFileNames are lockfiles and package databases: their entries are
+// resolved or installed, so they are not direct dependencies of the project
+// unless the file itself says so.
+var resolvedFileNames = map[string]bool{
+	"package-lock.json": true, "npm-shrinkwrap.json": true, "yarn.lock": true, "pnpm-lock.yaml": true,
+	"bun.lock": true, "deno.lock": true, "cargo.lock": true, "gemfile.lock": true, "composer.lock": true,
+	"poetry.lock": true, "pipfile.lock": true, "uv.lock": true, "pdm.lock": true, "go.sum": true,
+	"gradle.lockfile": true, "maven_install.json": true, "module.bazel.lock": true, "packages.lock.json": true,
+	"pubspec.lock": true, "podfile.lock": true, "package.resolved": true, "cartfile.resolved": true,
+	"mix.lock": true, "rebar.lock": true, "conan.lock": true, "renv.lock": true, "manifest.toml": true,
+	"juliamanifest.toml": true, "cabal.project.freeze": true, "stack.yaml.lock": true, "pixi.lock": true,
+	"conda-lock.yml": true, "conda-lock.yaml": true, "chart.lock": true, ".terraform.lock.hcl": true,
+	"modules.txt": true, "status": true, "installed": true, "rpmdb.sqlite": true,
+}
+
+/************************************
+* Function Name: declaredDirect
+* Purpose: Report whether a dependency entry is a direct dependency of the
+*          scanned project: declared in a manifest (no evidence note, not
+*          transitive or indirect), or marked direct by its lockfile.
+* Parameters: file string, notes []string
+* Output: bool
+*************************************/
+func declaredDirect(file string, notes []string) bool {
+	switch {
+	case hasNote(notes, "direct"):
+		return true
+	case hasNote(notes, "transitive"), hasNote(notes, "indirect"):
+		return false
+	}
+	if evidence, _ := depNote(notes, "evidence"); evidence != "" {
+		return false
+	}
+	file = filepath.ToSlash(file)
+	return !resolvedFileNames[strings.ToLower(path.Base(file))] && !strings.Contains(file, "dpkg/status.d/")
+}
+
+/************************************
+* Function Name: cyclonedxComponents
+* Purpose: Convert the dependencies of an Analysis into CycloneDX components.
+*          The bom-ref is the purl (or ecosystem:name@version without one), so
+*          it is stable across runs and a package found in several manifests
+*          becomes a single component listing every file.
+* Parameters: a Analysis
+* Output: []cdxComponent (sorted by bom-ref), []string (refs of direct dependencies, see declaredDirect)
+*************************************/
+func cyclonedxComponents(a Analysis) ([]cdxComponent, []string) {
+	byRef := map[string]*cdxComponent{}
+	direct := map[string]struct{}{}
+	for eco, perFile := range a.Dependencies {
+		for file, deps := range perFile {
+			for _, dep := range deps {
+				if strings.Contains(dep, " => ") {
+					continue // go.mod replace directives are not packages
+				}
+				name, version, notes := splitDep(dep)
+				if name == "" {
+					continue
+				}
+				purl := packageURL(eco, dep)
+				ref := cyclonedxRef(eco, name, version, purl)
+				c, ok := byRef[ref]
+				if !ok {
+					c = &cdxComponent{Type: "library", BOMRef: ref, Name: name, PURL: purl}
+					if strings.HasPrefix(purl, "pkg:docker/") {
+						c.Type = "container"
+					}
+					if isExactVersion(version) {
+						c.Version = version
+					} else if version != "" {
+						addProperty(c, "sca-cli:requirement", version)
+					}
+					addProperty(c, "sca-cli:ecosystem", eco)
+					byRef[ref] = c
+				}
+				if scope := cyclonedxScope(notes); c.Scope == "" || cyclonedxScopeRank[scope] > cyclonedxScopeRank[c.Scope] {
+					c.Scope = scope
+				}
+				for key, alg := range cyclonedxHashAlgs {
+					if sum, _ := depNote(notes, key); hexDigestRe.MatchString(sum) {
+						c.Hashes = appendHash(c.Hashes, cdxHash{Alg: alg, Content: strings.ToLower(sum)})
+					}
+				}
+				if digest, _ := depNote(notes, "digest"); strings.HasPrefix(digest, "sha256:") {
+					c.Hashes = appendHash(c.Hashes, cdxHash{Alg: "SHA-256", Content: strings.TrimPrefix(digest, "sha256:")})
+				}
+				if license, _ := depNote(notes, "license"); license != "" && c.Licenses == nil {
+					c.Licenses = cyclonedxLicenses(license)
+				}
+				if evidence, _ := depNote(notes, "evidence"); evidence != "" {
+					addProperty(c, "sca-cli:evidence", evidence)
+				}
+				addProperty(c, "sca-cli:file", file)
+				if declaredDirect(file, notes) {
+					direct[ref] = struct{}{}
+				}
+			}
+		}
+	}
+
+	refs := make([]string, 0, len(byRef))
+	for ref := range byRef {
+		refs = append(refs, ref)
+	}
+	sort.Strings(refs)
+	components := make([]cdxComponent, 0, len(refs))
+	for _, ref := range refs {
+		c := byRef[ref]
+		sort.Slice(c.Hashes, func(i, j int) bool { return c.Hashes[i].Alg < c.Hashes[j].Alg })
+		sort.SliceStable(c.Properties, func(i, j int) bool { return c.Properties[i].Name < c.Properties[j].Name })
+		components = append(components, *c)
+	}
+	return components, setToSortedSlice(direct)
+}
+
+/************************************
+* Function Name: cyclonedxRef
+* Purpose: Build the bom-ref of a dependency: its purl, or
+*          ecosystem:name@version when it has none.
+* Parameters: eco string, name string, version string, purl string
+* Output: string
+*************************************/
+func cyclonedxRef(eco, name, version, purl string) string {
+	if purl != "" {
+		return purl
+	}
+	ref := eco + ":" + name
+	if version != "" {
+		ref += "@" + version
+	}
+	return ref
+}
+
+/************************************
+* Function Name: appendHash
+* Purpose: Add a hash to a list unless the algorithm is already present.
+* Parameters: hashes cdxHashes, h cdxHash
+* Output: cdxHashes
+*************************************/
+func appendHash(hashes cdxHashes, h cdxHash) cdxHashes {
+	for _, x := range hashes {
+		if x.Alg == h.Alg {
+			return hashes
+		}
+	}
+	return append(hashes, h)
+}
+
+/************************************
+* Function Name: cyclonedxDependencies
+* Purpose: Build the dependency graph of a BOM: the root depends on the direct
+*          dependencies and each component on the packages its lockfile
+*          lists for it. Edges to packages that are not components are dropped.
+* Parameters: a Analysis, rootRef string, components []cdxComponent, direct []string
+* Output: []cdxDependency (root first, then one entry per component)
+*************************************/
+func cyclonedxDependencies(a Analysis, rootRef string, components []cdxComponent, direct []string) []cdxDependency {
+	ref := func(eco, dep string) string {
+		name, version, _ := splitDep(dep)
+		return cyclonedxRef(eco, name, version, packageURL(eco, dep))
+	}
+	graph := map[string]map[string]struct{}{}
+	for _, c := range components {
+		graph[c.BOMRef] = map[string]struct{}{}
+	}
+	for eco, edges := range a.dependsOn {
+		for parent, children := range edges {
+			from, ok := graph[ref(eco, parent)]
+			if !ok {
+				continue
+			}
+			for _, child := range children {
+				if to := ref(eco, child); graph[to] != nil && to != ref(eco, parent) {
+					from[to] = struct{}{}
+				}
+			}
+		}
+	}
+
+	dependencies := []cdxDependency{{Ref: rootRef, DependsOn: direct}}
+	for _, c := range components {
+		dependencies = append(dependencies, cdxDependency{Ref: c.BOMRef, DependsOn: setToSortedSlice(graph[c.BOMRef])})
+	}
+	return dependencies
+}
+
+/************************************
+* Function Name: cyclonedxBOM
+* Purpose: Render an Analysis as a CycloneDX SBOM (JSON or XML). The scanned
+*          repository is the metadata component (its commit as the version
+*          and its URL as the vcs reference) and depends on every direct
+*          dependency; every component gets a dependency entry holding the
+*          edges its lockfile records (empty when none are known).
+* Parameters: a Analysis, specVersion string ("1.5" or "1.6"), asXML bool
+* Output: []byte, error
+*************************************/
+func cyclonedxBOM(a Analysis, specVersion string, asXML bool) ([]byte, error) {
+	if specVersion != "1.5" && specVersion != "1.6" {
+		return nil, fmt.Errorf("unsupported CycloneDX version %q (use 1.5 or 1.6)", specVersion)
+	}
+	components, direct := cyclonedxComponents(a)
+
+	root := &cdxComponent{Type: "application", BOMRef: a.Repo, Name: a.Repo, Version: a.Commit}
+	if a.VCS != "" {
+		root.ExternalReferences = cdxExternalReferences{{Type: "vcs", URL: a.VCS}}
+	}
+	bom := cdxBOM{
+		XMLNS:        "http://cyclonedx.org/schema/bom/" + specVersion,
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  specVersion,
+		SerialNumber: "urn:uuid:" + newUUID(),
+		Version:      1,
+		Metadata: cdxMetadata{
+			Timestamp: time.Now().UTC().Format(time.RFC3339),
+			Tools:     cdxTools{Components: []cdxComponent{{Type: "application", Name: "sca-cli"}}},
+			Component: root,
+		},
+		Components:   components,
+		Dependencies: cyclonedxDependencies(a, root.BOMRef, components, direct),
+	}
+	if asXML {
+		out, err := xml.MarshalIndent(bom, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte(xml.Header), out...), nil
+	}
+	return json.MarshalIndent(bom, "", "  ")
+}
@@ -1,7 +1,7 @@
 package main
 
 import (
-	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
@@ -17,44 +17,66 @@ func pathExists(path string) bool {
 	if path == "" {
 		return false
 	}
-	_, err := os.Stat(path)
+	_, err := fs.Stat(sourceFS, filepath.ToSlash(path))
 	return err == nil
 }
 
 /************************************
 * Function Name: detectPackageManagers
 * Purpose: Walks a repository tree and detects files that indicate
-*          which package managers or ecosystems are in use.
-* Parameters: root string
+*          which package managers or ecosystems are in use. Directories in
+*          skip (e.g. submodules that should not be scanned) are not entered.
+* Parameters: root string, skip []string
 * Output: map[string][]string, error
 *************************************/
-func detectPackageManagers(root string) (map[string][]string, error) {
+func detectPackageManagers(root string, skip []string) (map[string][]string, error) {
 	found := make(map[string][]string)
+	skipDirs := map[string]bool{}
+	for _, d := range skip {
+		skipDirs[filepath.Clean(d)] = true
+	}
 
 	walkFn := func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return nil
 		}
 		if info.IsDir() {
-			// skip .git
-			if info.Name() == ".git" {
+			// skip .git, and .terraform which holds downloaded modules and providers
+			if info.Name() == ".git" || info.Name() == ".terraform" || skipDirs[filepath.Clean(path)] {
+				return filepath.SkipDir
+			}
+			// installed package trees are read as a whole by their parser
+			if key := installedTreeKey(path, strings.ToLower(info.Name())); key != "" {
+				found[key] = append(found[key], path)
+				return filepath.SkipDir
+			}
+			// unpacked gem sources of a gem home; the gems are listed from specifications
+			if info.Name() == "gems" && pathExists(filepath.Join(filepath.Dir(path), "specifications")) {
 				return filepath.SkipDir
 			}
 			return nil
 		}
 		name := strings.ToLower(info.Name())
 
+		// files copied from known library releases, matched by content
+		if fingerprintDB.candidate(path, info) {
+			if _, _, ok := fingerprintDB.match(path); ok {
+				found["fingerprint"] = append(found["fingerprint"], path)
+			}
+		}
+
 		switch name {
 		case "go.mod":
 			found["go"] = append(found["go"], path)
 		case "package.json":
 			found["node/npm"] = append(found["node/npm"], path)
+		case "bun.lock", "pnpm-workspace.yaml":
+			found["node/npm"] = append(found["node/npm"], path)
+		case "deno.json", "deno.jsonc", "deno.lock":
+			found["deno"] = append(found["deno"], path)
 		case "yarn.lock":
 			found["node/yarn"] = append(found["node/yarn"], path)
-		case "requirements.txt":
-			fmt.Printf("Detected requirements.txt at: %s\n", path) // Debug log
-			found["python"] = append(found["python"], path)
-		case "setup.py", "pipfile", "pyproject.toml":
+		case "requirements.txt", "setup.py", "pipfile", "pyproject.toml":
 			found["python"] = append(found["python"], path)
 		case "pom.xml":
 			found["maven"] = append(found["maven"], path)
@@ -66,15 +88,154 @@ func detectPackageManagers(root string) (map[string][]string, error) {
 			found["ruby"] = append(found["ruby"], path)
 		case "cargo.toml":
 			found["rust"] = append(found["rust"], path)
-		case "package.swift":
+		case "package.swift", "package.resolved":
 			found["swift"] = append(found["swift"], path)
+		case "podfile", "podfile.lock":
+			found["cocoapods"] = append(found["cocoapods"], path)
+		case "cartfile", "cartfile.private", "cartfile.resolved":
+			found["carthage"] = append(found["carthage"], path)
+		case "directory.packages.props", "directory.build.props", "packages.config", "packages.lock.json":
+			found["nuget"] = append(found["nuget"], path)
+		case "pubspec.yaml", "pubspec.lock":
+			found["dart"] = append(found["dart"], path)
+		case "mix.exs", "mix.lock", "rebar.config", "rebar.lock":
+			found["hex"] = append(found["hex"], path)
+		case "conanfile.txt", "conanfile.py", "conan.lock":
+			found["conan"] = append(found["conan"], path)
+		case "vcpkg.json":
+			found["vcpkg"] = append(found["vcpkg"], path)
+		case "cmakelists.txt":
+			found["cmake"] = append(found["cmake"], path)
+		case "project.clj", "deps.edn":
+			found["clojure"] = append(found["clojure"], path)
+		case "maven_install.json", "module.bazel":
+			found["bazel"] = append(found["bazel"], path)
+		case "description", "renv.lock":
+			found["cran"] = append(found["cran"], path)
+		case "project.toml", "juliaproject.toml", "manifest.toml", "juliamanifest.toml":
+			found["julia"] = append(found["julia"], path)
+		case "stack.yaml", "cabal.project.freeze":
+			found["hackage"] = append(found["hackage"], path)
+		case "environment.yml", "environment.yaml", "conda-lock.yml", "conda-lock.yaml", "pixi.toml", "pixi.lock":
+			found["conda"] = append(found["conda"], path)
+		case "status":
+			if strings.HasSuffix(filepath.ToSlash(path), "var/lib/dpkg/status") {
+				found["dpkg"] = append(found["dpkg"], path)
+			}
+		case "installed":
+			if strings.HasSuffix(filepath.ToSlash(path), "lib/apk/db/installed") {
+				found["apk"] = append(found["apk"], path)
+			}
+		case "rpmdb.sqlite":
+			found["rpm"] = append(found["rpm"], path)
+		case "modules.txt":
+			if filepath.Base(filepath.Dir(path)) == "vendor" {
+				found["go/vendor"] = append(found["go/vendor"], path)
+			}
+		case ".gitmodules":
+			found["git-submodule"] = append(found["git-submodule"], path)
+		case "chart.yaml", "chart.lock":
+			found["helm"] = append(found["helm"], path)
+		case ".terraform.lock.hcl":
+			found["terraform"] = append(found["terraform"], path)
+		case "action.yml", "action.yaml":
+			found["github-actions"] = append(found["github-actions"], path)
+		case "dockerfile", "containerfile", "compose.yml", "compose.yaml":
+			found["docker"] = append(found["docker"], path)
+		default:
+			if strings.HasPrefix(name, "dockerfile.") || strings.HasPrefix(name, "containerfile.") || strings.HasSuffix(name, ".dockerfile") ||
+				(strings.HasPrefix(name, "docker-compose") && (strings.HasSuffix(name, ".yml") || strings.HasSuffix(name, ".yaml"))) {
+				found["docker"] = append(found["docker"], path)
+				return nil
+			}
+			if ext := filepath.Ext(name); (ext == ".yml" || ext == ".yaml") &&
+				filepath.Base(filepath.Dir(path)) == "workflows" && filepath.Base(filepath.Dir(filepath.Dir(path))) == ".github" {
+				found["github-actions"] = append(found["github-actions"], path)
+				return nil
+			}
+			// chart values and Kubernetes manifests are container image sources
+			if ext := filepath.Ext(name); (ext == ".yml" || ext == ".yaml") && (isHelmValuesFile(path) || isKubernetesManifest(path)) {
+				found["docker"] = append(found["docker"], path)
+				return nil
+			}
+			// distroless images keep one dpkg status file per package
+			if strings.HasSuffix(filepath.ToSlash(filepath.Dir(path)), "var/lib/dpkg/status.d") && filepath.Ext(name) != ".md5sums" {
+				found["dpkg"] = append(found["dpkg"], path)
+				return nil
+			}
+			switch filepath.Ext(name) {
+			case ".csproj", ".fsproj":
+				found["nuget"] = append(found["nuget"], path)
+			case ".sbt":
+				found["sbt"] = append(found["sbt"], path)
+			case ".cabal":
+				found["hackage"] = append(found["hackage"], path)
+			case ".tf":
+				found["terraform"] = append(found["terraform"], path)
+			case ".jar", ".war", ".ear":
+				found["java-archive"] = append(found["java-archive"], path)
+			}
 		}
 		return nil
 	}
 
-	err := filepath.Walk(root, walkFn)
+	err := walkSource(root, walkFn)
 	if err != nil {
 		return nil, err
 	}
 	return found, nil
 }
+
+// vendoredDirNames are directory names that conventionally hold third-party code
+// copied into a repository.
+var vendoredDirNames = map[string]bool{
+	"vendor":      true,
+	"third_party": true,
+	"third-party": true,
+	"thirdparty":  true,
+	"external":    true,
+}
+
+/************************************
+* Function Name: findVendoredDirs
+* Purpose: List the directories of a repository that hold vendored third-party
+*          code (vendor/, third_party/, external/). Nested vendored directories
+*          are covered by their outermost parent.
+* Parameters: root string
+* Output: []string (paths relative to root)
+*************************************/
+func findVendoredDirs(root string) []string {
+	var dirs []string
+	walkSource(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.IsDir() {
+			return nil
+		}
+		if info.Name() == ".git" || info.Name() == "node_modules" {
+			return filepath.SkipDir
+		}
+		if path != root && vendoredDirNames[strings.ToLower(info.Name())] {
+			if rel, err := filepath.Rel(root, path); err == nil {
+				dirs = append(dirs, filepath.ToSlash(rel))
+			}
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	return dirs
+}
+
+/************************************
+* Function Name: vendoredDir
+* Purpose: Return the vendored directory containing a relative file path, if any.
+* Parameters: rel string, vendored []string
+* Output: string
+*************************************/
+func vendoredDir(rel string, vendored []string) string {
+	rel = filepath.ToSlash(rel)
+	for _, d := range vendored {
+		if strings.HasPrefix(rel, d+"/") {
+			return d
+		}
+	}
+	return ""
+}
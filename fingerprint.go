@@ -0,0 +1,244 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// fingerprintMaxSize bounds the files that are hashed during the walk.
+const fingerprintMaxSize = 8 << 20
+
+// fingerprintEntry is one known file of a library release.
+type fingerprintEntry struct {
+	Ecosystem        string `json:"ecosystem"` // display name, e.g. "Node", "Generic"
+	Name             string `json:"name"`
+	Version          string `json:"version"`
+	File             string `json:"file"`
+	SHA256           string `json:"sha256"`
+	NormalizedSHA256 string `json:"normalized_sha256"`
+}
+
+// fingerprintDatabase indexes the entries of an offline fingerprint database.
+type fingerprintDatabase struct {
+	exact      map[string]fingerprintEntry
+	normalized map[string]fingerprintEntry
+	exts       map[string]bool
+	matched    map[string]fingerprintMatch // per path, filled during the discovery walk
+}
+
+// fingerprintMatch is the lookup result of one hashed file.
+type fingerprintMatch struct {
+	entry fingerprintEntry
+	kind  string
+	ok    bool
+}
+
+// fingerprintDB is the database loaded with -fingerprints; nil disables matching.
+var fingerprintDB *fingerprintDatabase
+
+/************************************
+* Function Name: loadFingerprintDB
+* Purpose: Load an offline fingerprint database: a JSON document of the form
+*          {"entries": [{ecosystem, name, version, file, sha256,
+*          normalized_sha256}, ...]}. Only files whose extension appears in
+*          the database are hashed later on.
+* Parameters: path string
+* Output: *fingerprintDatabase, error
+*************************************/
+func loadFingerprintDB(path string) (*fingerprintDatabase, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var data struct {
+		Entries []fingerprintEntry `json:"entries"`
+	}
+	if err := json.Unmarshal(b, &data); err != nil {
+		return nil, fmt.Errorf("invalid fingerprint database %s: %v", path, err)
+	}
+	db := &fingerprintDatabase{
+		exact:      map[string]fingerprintEntry{},
+		normalized: map[string]fingerprintEntry{},
+		exts:       map[string]bool{},
+		matched:    map[string]fingerprintMatch{},
+	}
+	for _, e := range data.Entries {
+		if e.Name == "" {
+			continue
+		}
+		if e.Ecosystem == "" {
+			e.Ecosystem = "Generic"
+		}
+		if e.SHA256 != "" {
+			db.exact[strings.ToLower(e.SHA256)] = e
+		}
+		if e.NormalizedSHA256 != "" {
+			db.normalized[strings.ToLower(e.NormalizedSHA256)] = e
+		}
+		db.exts[strings.ToLower(filepath.Ext(e.File))] = true
+	}
+	return db, nil
+}
+
+// fingerprintCommentStyles maps extensions to the comment syntax stripped
+// before the normalized hash: "c" for /* */ and //, "hash" for #.
+var fingerprintCommentStyles = map[string]string{
+	".js": "c", ".mjs": "c", ".cjs": "c", ".ts": "c", ".css": "c", ".scss": "c", ".less": "c",
+	".c": "c", ".h": "c", ".cc": "c", ".cpp": "c", ".hpp": "c", ".java": "c", ".go": "c",
+	".swift": "c", ".kt": "c", ".cs": "c", ".php": "c",
+	".py": "hash", ".rb": "hash", ".sh": "hash", ".pl": "hash", ".r": "hash",
+}
+
+/************************************
+* Function Name: normalizeForFingerprint
+* Purpose: Produce the normalized form of a file used for the secondary hash:
+*          a UTF-8 byte order mark, comments (by the file extension) and all
+*          whitespace are removed, so line ending, indentation, license header
+*          and whitespace-only minification changes still match. Minifiers
+*          also rename identifiers and rewrite expressions, so a foo.min.js
+*          only matches an entry for the released foo.min.js itself.
+* Parameters: b []byte, ext string
+* Output: []byte
+*************************************/
+func normalizeForFingerprint(b []byte, ext string) []byte {
+	b = bytes.TrimPrefix(b, []byte("\ufeff"))
+	switch fingerprintCommentStyles[strings.ToLower(ext)] {
+	case "c":
+		b = stripFingerprintComments(b, true)
+	case "hash":
+		b = stripFingerprintComments(b, false)
+	}
+	return bytes.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, b)
+}
+
+/************************************
+* Function Name: stripFingerprintComments
+* Purpose: Remove comments outside of string literals: block and // line
+*          comments for C-style sources, # line comments otherwise.
+* Parameters: b []byte, cStyle bool
+* Output: []byte
+*************************************/
+func stripFingerprintComments(b []byte, cStyle bool) []byte {
+	out := make([]byte, 0, len(b))
+	var quote byte
+	for i := 0; i < len(b); i++ {
+		c := b[i]
+		if quote != 0 {
+			out = append(out, c)
+			if c == '\\' && i+1 < len(b) {
+				i++
+				out = append(out, b[i])
+			} else if c == quote || c == '\n' {
+				quote = 0
+			}
+			continue
+		}
+		switch {
+		case c == '"' || c == '\'' || (cStyle && c == '`'):
+			quote = c
+		case cStyle && c == '/' && i+1 < len(b) && b[i+1] == '*':
+			end := bytes.Index(b[i+2:], []byte("*/"))
+			if end < 0 {
+				return out
+			}
+			i += end + 3
+			continue
+		case (cStyle && c == '/' && i+1 < len(b) && b[i+1] == '/') || (!cStyle && c == '#'):
+			end := bytes.IndexByte(b[i:], '\n')
+			if end < 0 {
+				return out
+			}
+			i += end - 1
+			continue
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+/************************************
+* Function Name: candidate
+* Purpose: Report whether a file is worth hashing: its extension is known to
+*          the database and it does not live in an installed node_modules tree.
+* Parameters: path string, info os.FileInfo
+* Output: bool
+*************************************/
+func (db *fingerprintDatabase) candidate(path string, info os.FileInfo) bool {
+	if db == nil || info.Size() > fingerprintMaxSize || info.Size() == 0 {
+		return false
+	}
+	if strings.Contains(filepath.ToSlash(path), "/node_modules/") {
+		return false
+	}
+	return db.exts[strings.ToLower(filepath.Ext(path))]
+}
+
+/************************************
+* Function Name: match
+* Purpose: Hash a file and look it up, first by its exact SHA-256 and then by
+*          the SHA-256 of its normalized content. The result is kept per path
+*          so the file is read once, during the discovery walk.
+* Parameters: path string
+* Output: fingerprintEntry, string (match kind: exact or normalized), bool
+*************************************/
+func (db *fingerprintDatabase) match(path string) (fingerprintEntry, string, bool) {
+	if m, ok := db.matched[path]; ok {
+		return m.entry, m.kind, m.ok
+	}
+	m := db.lookup(path)
+	db.matched[path] = m
+	return m.entry, m.kind, m.ok
+}
+
+/************************************
+* Function Name: lookup
+* Purpose: Read and hash a file for match.
+* Parameters: path string
+* Output: fingerprintMatch
+*************************************/
+func (db *fingerprintDatabase) lookup(path string) fingerprintMatch {
+	b, err := readSourceFile(path)
+	if err != nil {
+		return fingerprintMatch{}
+	}
+	sum := sha256.Sum256(b)
+	if e, ok := db.exact[hex.EncodeToString(sum[:])]; ok {
+		return fingerprintMatch{e, "exact", true}
+	}
+	sum = sha256.Sum256(normalizeForFingerprint(b, filepath.Ext(path)))
+	if e, ok := db.normalized[hex.EncodeToString(sum[:])]; ok {
+		return fingerprintMatch{e, "normalized", true}
+	}
+	return fingerprintMatch{}
+}
+
+/************************************
+* Function Name: parseFingerprintDeps
+* Purpose: Turn a fingerprinted file into a dependency entry of the ecosystem
+*          recorded in the database.
+* Parameters: path string
+* Output: ecosystem string, dep string (empty when the file no longer matches)
+*************************************/
+func parseFingerprintDeps(path string) (string, string) {
+	e, kind, ok := fingerprintDB.match(path)
+	if !ok {
+		return "", ""
+	}
+	dep := e.Name
+	if e.Version != "" {
+		dep = fmt.Sprintf("%s@%s", e.Name, e.Version)
+	}
+	return e.Ecosystem, annotateDep(dep, "evidence: fingerprint", "match: "+kind)
+}
@@ -0,0 +1,335 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// sourceFS is the filesystem the detectors and parsers read from. It is the
+// host filesystem, except while a source archive is scanned (-source).
+var sourceFS fs.FS = hostFS{}
+
+// hostFS serves host paths as they are (absolute, or relative to the working
+// directory), so directory scans keep reporting real paths.
+type hostFS struct{}
+
+func (hostFS) Open(name string) (fs.File, error)          { return os.Open(name) }
+func (hostFS) ReadFile(name string) ([]byte, error)       { return os.ReadFile(name) }
+func (hostFS) Stat(name string) (fs.FileInfo, error)      { return os.Stat(name) }
+func (hostFS) ReadDir(name string) ([]fs.DirEntry, error) { return os.ReadDir(name) }
+
+/************************************
+* Function Name: readSourceFile
+* Purpose: Read a file of the scanned source tree.
+* Parameters: path string
+* Output: []byte, error
+*************************************/
+func readSourceFile(path string) ([]byte, error) {
+	return fs.ReadFile(sourceFS, filepath.ToSlash(path))
+}
+
+/************************************
+* Function Name: readSourceDir
+* Purpose: List a directory of the scanned source tree.
+* Parameters: path string
+* Output: []fs.DirEntry, error
+*************************************/
+func readSourceDir(path string) ([]fs.DirEntry, error) {
+	return fs.ReadDir(sourceFS, filepath.ToSlash(path))
+}
+
+/************************************
+* Function Name: globSource
+* Purpose: Match a glob pattern against the scanned source tree.
+* Parameters: pattern string
+* Output: []string, error
+*************************************/
+func globSource(pattern string) ([]string, error) {
+	matches, err := fs.Glob(sourceFS, filepath.ToSlash(pattern))
+	for i, m := range matches {
+		matches[i] = filepath.FromSlash(m)
+	}
+	return matches, err
+}
+
+/************************************
+* Function Name: walkSource
+* Purpose: Walk the scanned source tree like filepath.Walk (lexical order,
+*          symbolic links are not followed, filepath.SkipDir is honoured).
+* Parameters: root string, fn filepath.WalkFunc
+* Output: error
+*************************************/
+func walkSource(root string, fn filepath.WalkFunc) error {
+	return fs.WalkDir(sourceFS, filepath.ToSlash(root), func(p string, d fs.DirEntry, err error) error {
+		p = filepath.FromSlash(p)
+		if err != nil {
+			return fn(p, nil, err)
+		}
+		info, err := d.Info()
+		if err != nil {
+			return fn(p, nil, err)
+		}
+		return fn(p, info, nil)
+	})
+}
+
+// archiveMaxEntrySize bounds the tar entries held in memory; larger files are
+// left out of the scan.
+const archiveMaxEntrySize = 64 << 20
+
+// archiveMaxTotalSize bounds the sum of the tar entries held in memory.
+const archiveMaxTotalSize = 1 << 30
+
+// memEntry is a file or directory of a memFS; it is its own FileInfo and DirEntry.
+type memEntry struct {
+	name    string // base name
+	data    []byte
+	dir     bool
+	modTime time.Time
+	entries []fs.DirEntry // sorted children of a directory
+}
+
+func (e *memEntry) Name() string               { return e.name }
+func (e *memEntry) Size() int64                { return int64(len(e.data)) }
+func (e *memEntry) ModTime() time.Time         { return e.modTime }
+func (e *memEntry) IsDir() bool                { return e.dir }
+func (e *memEntry) Sys() interface{}           { return nil }
+func (e *memEntry) Type() fs.FileMode          { return e.Mode().Type() }
+func (e *memEntry) Info() (fs.FileInfo, error) { return e, nil }
+func (e *memEntry) Mode() fs.FileMode {
+	if e.dir {
+		return fs.ModeDir | 0o555
+	}
+	return 0o444
+}
+
+// memFile is an open memEntry.
+type memFile struct {
+	entry  *memEntry
+	reader *bytes.Reader
+	offset int // next directory entry returned by ReadDir
+}
+
+func (f *memFile) Stat() (fs.FileInfo, error) { return f.entry, nil }
+func (f *memFile) Close() error               { return nil }
+func (f *memFile) Read(b []byte) (int, error) {
+	if f.entry.dir {
+		return 0, &fs.PathError{Op: "read", Path: f.entry.name, Err: fs.ErrInvalid}
+	}
+	return f.reader.Read(b)
+}
+
+func (f *memFile) ReadAt(b []byte, off int64) (int, error) {
+	if f.entry.dir {
+		return 0, &fs.PathError{Op: "read", Path: f.entry.name, Err: fs.ErrInvalid}
+	}
+	return f.reader.ReadAt(b, off)
+}
+
+func (f *memFile) ReadDir(n int) ([]fs.DirEntry, error) {
+	rest := f.entry.entries[f.offset:]
+	if n <= 0 {
+		f.offset += len(rest)
+		return rest, nil
+	}
+	if len(rest) == 0 {
+		return nil, io.EOF
+	}
+	if n > len(rest) {
+		n = len(rest)
+	}
+	f.offset += n
+	return rest[:n], nil
+}
+
+// memFS is a read-only in-memory filesystem holding the entries of a tar stream.
+type memFS struct {
+	files map[string]*memEntry // by slash path, "." is the root
+}
+
+func (m *memFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	e, ok := m.files[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return &memFile{entry: e, reader: bytes.NewReader(e.data)}, nil
+}
+
+/************************************
+* Function Name: memDir
+* Purpose: Return the directory entry for a slash path of a memFS, creating it
+*          and its parents on first use (tar streams may omit directories).
+* Parameters: name string
+* Output: *memEntry
+*************************************/
+func (m *memFS) memDir(name string) *memEntry {
+	if e, ok := m.files[name]; ok && e.dir {
+		return e
+	}
+	e := &memEntry{name: path.Base(name), dir: true}
+	m.files[name] = e
+	if name != "." {
+		m.memDir(path.Dir(name))
+	}
+	return e
+}
+
+/************************************
+* Function Name: loadTarFS
+* Purpose: Read a tar stream into a memFS. Regular files and directories are
+*          kept; links, devices and files above archiveMaxEntrySize are
+*          skipped. A later entry for the same path replaces an earlier one.
+*          An archive holding more than archiveMaxTotalSize is an error.
+* Parameters: r io.Reader
+* Output: *memFS, error
+*************************************/
+func loadTarFS(r io.Reader) (*memFS, error) {
+	m := &memFS{files: map[string]*memEntry{}}
+	m.memDir(".")
+	var total int64
+	tr := tar.NewReader(r)
+	for count := 0; ; count++ {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if count == 0 {
+				return nil, fmt.Errorf("not a zip or tar archive")
+			}
+			return nil, err
+		}
+		name := strings.TrimPrefix(path.Clean("/"+hdr.Name), "/")
+		if name == "" {
+			continue
+		}
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			m.memDir(name).modTime = hdr.ModTime
+		case tar.TypeReg:
+			if hdr.Size > archiveMaxEntrySize {
+				continue
+			}
+			if total += hdr.Size; total > archiveMaxTotalSize {
+				return nil, fmt.Errorf("archive holds more than %d MiB of files", archiveMaxTotalSize>>20)
+			}
+			data, err := io.ReadAll(tr)
+			if err != nil {
+				return nil, err
+			}
+			m.memDir(path.Dir(name))
+			m.files[name] = &memEntry{name: path.Base(name), data: data, modTime: hdr.ModTime}
+		}
+	}
+	// link every entry to its parent directory
+	for name, e := range m.files {
+		if name != "." {
+			parent := m.files[path.Dir(name)]
+			parent.entries = append(parent.entries, e)
+		}
+	}
+	for _, e := range m.files {
+		sort.Slice(e.entries, func(i, j int) bool { return e.entries[i].Name() < e.entries[j].Name() })
+	}
+	return m, nil
+}
+
+/************************************
+* Function Name: openDecompressed
+* Purpose: Open a file and undo its compression, recognised by magic bytes:
+*          gzip and bzip2 natively, xz and zstd through the xz / zstd
+*          commands. Uncompressed files are read as they are.
+* Parameters: file string
+* Output: io.Reader, func() (closer), error
+*************************************/
+func openDecompressed(file string) (io.Reader, func(), error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	br := bufio.NewReader(f)
+	magic, _ := br.Peek(6)
+	var tool string
+	switch {
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			f.Close()
+			return nil, nil, err
+		}
+		return zr, func() { zr.Close(); f.Close() }, nil
+	case bytes.HasPrefix(magic, []byte("BZh")):
+		return bzip2.NewReader(br), func() { f.Close() }, nil
+	case bytes.Equal(magic, []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}):
+		tool = "xz"
+	case bytes.HasPrefix(magic, []byte{0x28, 0xb5, 0x2f, 0xfd}):
+		tool = "zstd"
+	default:
+		return br, func() { f.Close() }, nil
+	}
+
+	cmd := exec.Command(tool, "-dc")
+	cmd.Stdin = br
+	out, err := cmd.StdoutPipe()
+	if err == nil {
+		err = cmd.Start()
+	}
+	if err != nil {
+		f.Close()
+		return nil, nil, fmt.Errorf("%s: %s decompression needs the %s command: %v", filepath.Base(file), tool, tool, err)
+	}
+	return out, func() { out.Close(); cmd.Wait(); f.Close() }, nil
+}
+
+/************************************
+* Function Name: openSourceArchive
+* Purpose: Open a zip or tar (optionally gzip, bzip2, xz or zstd compressed)
+*          archive as a filesystem, without unpacking it to disk. Zip files
+*          are read in place; tar entries are streamed into memory.
+* Parameters: file string
+* Output: fs.FS, func() (closer), error
+*************************************/
+func openSourceArchive(file string) (fs.FS, func(), error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	magic := make([]byte, 4)
+	n, _ := io.ReadFull(f, magic)
+	f.Close()
+	if n == 4 && (bytes.Equal(magic, []byte("PK\x03\x04")) || bytes.Equal(magic, []byte("PK\x05\x06"))) {
+		zr, err := zip.OpenReader(file)
+		if err != nil {
+			return nil, nil, err
+		}
+		return zr, func() { zr.Close() }, nil
+	}
+
+	r, closeFile, err := openDecompressed(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer closeFile()
+	m, err := loadTarFS(r)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %v", filepath.Base(file), err)
+	}
+	return m, func() {}, nil
+}
@@ -4,21 +4,70 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"strings"
 )
 
 /************************************
 * Function Name: cloneRepository
-* Purpose: Clones a git repository into a specified directory.
-* Parameters: repo string, dir string
+* Purpose: Clones a git repository into a specified directory, optionally
+*          fetching its submodules (shallow as well).
+* Parameters: repo string, dir string, submodules bool
 * Output: error
 *************************************/
-func cloneRepository(repo, dir string) error {
-	cmd := exec.Command("git", "clone", "--depth", "1", repo, dir)
+func cloneRepository(repo, dir string, submodules bool) error {
+	args := []string{"clone", "--depth", "1"}
+	if submodules {
+		args = append(args, "--recurse-submodules", "--shallow-submodules")
+	}
+	cmd := exec.Command("git", append(args, repo, dir)...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	return cmd.Run()
 }
 
+/************************************
+* Function Name: submoduleCommit
+* Purpose: Read the commit a submodule is pinned to from the git index of the
+*          superproject (git ls-tree HEAD -- <path>).
+* Parameters: repoDir string, subPath string
+* Output: string (empty when git is unavailable or the entry is not a gitlink)
+*************************************/
+func submoduleCommit(repoDir, subPath string) string {
+	out, err := exec.Command("git", "-C", repoDir, "ls-tree", "HEAD", "--", subPath).Output()
+	if err != nil {
+		return ""
+	}
+	// <mode> SP <type> SP <object> TAB <path>
+	fields := strings.Fields(string(out))
+	if len(fields) < 3 || fields[1] != "commit" {
+		return ""
+	}
+	return fields[2]
+}
+
+/************************************
+* Function Name: checkoutInfo
+* Purpose: Read the HEAD commit and the origin remote URL of a git checkout.
+*          Directories without their own .git are not asked, so a scan inside
+*          another repository does not report that repository's commit.
+* Parameters: repoDir string
+* Output: commit string, remote string (both empty when unknown)
+*************************************/
+func checkoutInfo(repoDir string) (string, string) {
+	if _, err := os.Stat(filepath.Join(repoDir, ".git")); err != nil {
+		return "", ""
+	}
+	commit, remote := "", ""
+	if out, err := exec.Command("git", "-C", repoDir, "rev-parse", "HEAD").Output(); err == nil {
+		commit = strings.TrimSpace(string(out))
+	}
+	if out, err := exec.Command("git", "-C", repoDir, "config", "--get", "remote.origin.url").Output(); err == nil {
+		remote = strings.TrimSpace(string(out))
+	}
+	return commit, remote
+}
+
 /************************************
 * Function Name: removePath
 * Purpose: Remove a filesystem path recursively with a basic safety check.
@@ -0,0 +1,387 @@
+package main
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// imageMaxDiskSize bounds the bytes an image scan writes to temporary disk:
+// the extracted archive plus the merged root filesystem.
+const imageMaxDiskSize = 8 << 30
+
+// imageManifest is the part of an OCI image manifest / index that is needed to
+// find the layers of an image.
+type imageManifest struct {
+	MediaType string            `json:"mediaType"`
+	Manifests []imageDescriptor `json:"manifests"`
+	Layers    []imageDescriptor `json:"layers"`
+}
+
+// imageDescriptor is an OCI content descriptor.
+type imageDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Annotations map[string]string `json:"annotations"`
+	Platform    *struct {
+		OS           string `json:"os"`
+		Architecture string `json:"architecture"`
+	} `json:"platform"`
+}
+
+/************************************
+* Function Name: safeJoin
+* Purpose: Join an archive member name below dir. The name is cleaned as an
+*          absolute path first, so ".." components cannot leave dir.
+* Parameters: dir string, name string
+* Output: string (empty for the archive root itself)
+*************************************/
+func safeJoin(dir, name string) string {
+	clean := strings.TrimPrefix(path.Clean("/"+name), "/")
+	if clean == "" {
+		return ""
+	}
+	return filepath.Join(dir, filepath.FromSlash(clean))
+}
+
+/************************************
+* Function Name: extractTar
+* Purpose: Extract the regular files and directories of a tar stream into dir.
+*          Hard links and relative symbolic links that stay inside the archive
+*          (legacy docker save links repeated layers to <id>/layer.tar) are
+*          copied from their target once the stream is read; other links and
+*          device files are skipped.
+* Parameters: r io.Reader, dir string, budget *int64 (bytes left on disk)
+* Output: error
+*************************************/
+func extractTar(r io.Reader, dir string, budget *int64) error {
+	type tarLink struct{ target, source string }
+	var links []tarLink
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		target := safeJoin(dir, hdr.Name)
+		if target == "" {
+			continue
+		}
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0o755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := writeTarFile(tr, target, budget); err != nil {
+				return err
+			}
+		case tar.TypeLink:
+			links = append(links, tarLink{target, safeJoin(dir, hdr.Linkname)})
+		case tar.TypeSymlink:
+			src := path.Join(path.Dir(hdr.Name), hdr.Linkname)
+			if path.IsAbs(hdr.Linkname) || src == ".." || strings.HasPrefix(src, "../") {
+				continue
+			}
+			links = append(links, tarLink{target, safeJoin(dir, src)})
+		}
+	}
+
+	// links may point at entries stored later, or at other links
+	for len(links) > 0 {
+		var pending []tarLink
+		for _, l := range links {
+			info, err := os.Stat(l.source)
+			if err != nil || !info.Mode().IsRegular() {
+				pending = append(pending, l)
+				continue
+			}
+			src, err := os.Open(l.source)
+			if err != nil {
+				continue
+			}
+			err = writeTarFile(src, l.target, budget)
+			src.Close()
+			if err != nil {
+				return err
+			}
+		}
+		if len(pending) == len(links) {
+			break
+		}
+		links = pending
+	}
+	return nil
+}
+
+/************************************
+* Function Name: writeTarFile
+* Purpose: Write a file from an archive, replacing whatever is at target
+*          (including a directory of a lower layer). Fails once the image
+*          budget is used up.
+* Parameters: r io.Reader, target string, budget *int64 (bytes left on disk)
+* Output: error
+*************************************/
+func writeTarFile(r io.Reader, target string, budget *int64) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+	if info, err := os.Lstat(target); err == nil && info.IsDir() {
+		os.RemoveAll(target)
+	}
+	f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	n, err := io.Copy(f, io.LimitReader(r, *budget+1))
+	*budget -= n
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil && *budget < 0 {
+		err = fmt.Errorf("image holds more than %d GiB of files", imageMaxDiskSize>>30)
+	}
+	return err
+}
+
+/************************************
+* Function Name: applyLayer
+* Purpose: Apply one image layer on top of rootfs. Whiteout files
+*          (.wh.<name>) delete the entry from lower layers and opaque markers
+*          (.wh..wh..opq) hide the lower contents of their directory. Hard
+*          links are copied from their target; symbolic links are skipped so
+*          that nothing is ever written outside rootfs.
+* Parameters: file string (layer blob), rootfs string, budget *int64 (bytes
+*          left on disk)
+* Output: error
+*************************************/
+func applyLayer(file, rootfs string, budget *int64) error {
+	r, closeLayer, err := openDecompressed(file)
+	if err != nil {
+		return err
+	}
+	defer closeLayer()
+
+	written := map[string]bool{} // entries added by this layer
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %v", filepath.Base(file), err)
+		}
+		target := safeJoin(rootfs, hdr.Name)
+		if target == "" {
+			continue
+		}
+		dir, base := filepath.Split(target)
+		switch {
+		case base == ".wh..wh..opq":
+			entries, _ := os.ReadDir(dir)
+			for _, e := range entries {
+				if p := filepath.Join(dir, e.Name()); !written[p] {
+					os.RemoveAll(p)
+				}
+			}
+			continue
+		case strings.HasPrefix(base, ".wh."):
+			if p := filepath.Join(dir, strings.TrimPrefix(base, ".wh.")); !written[p] {
+				os.RemoveAll(p)
+			}
+			continue
+		}
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if info, err := os.Lstat(target); err == nil && !info.IsDir() {
+				os.Remove(target)
+			}
+			if err := os.MkdirAll(target, 0o755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := writeTarFile(tr, target, budget); err != nil {
+				return err
+			}
+		case tar.TypeLink:
+			src, err := os.Open(safeJoin(rootfs, hdr.Linkname))
+			if err != nil {
+				continue
+			}
+			err = writeTarFile(src, target, budget)
+			src.Close()
+			if err != nil {
+				return err
+			}
+		default:
+			continue
+		}
+		written[target] = true
+		for d := filepath.Dir(target); d != rootfs && !written[d]; d = filepath.Dir(d) {
+			written[d] = true
+		}
+	}
+}
+
+/************************************
+* Function Name: pickManifest
+* Purpose: Choose the image manifest of an OCI index: the one for this
+*          machine's architecture when several platforms are present,
+*          otherwise the first one that is not an attestation.
+* Parameters: descs []imageDescriptor
+* Output: imageDescriptor, bool
+*************************************/
+func pickManifest(descs []imageDescriptor) (imageDescriptor, bool) {
+	var fallback *imageDescriptor
+	for i, d := range descs {
+		if d.Platform == nil {
+			if fallback == nil {
+				fallback = &descs[i]
+			}
+			continue
+		}
+		if d.Platform.OS == "unknown" {
+			continue // build attestations
+		}
+		if d.Platform.Architecture == runtime.GOARCH {
+			return d, true
+		}
+		if fallback == nil {
+			fallback = &descs[i]
+		}
+	}
+	if fallback == nil {
+		return imageDescriptor{}, false
+	}
+	return *fallback, true
+}
+
+/************************************
+* Function Name: imageLayers
+* Purpose: Find the ordered layer files and the name of the image in an
+*          extracted docker save archive (manifest.json) or OCI image layout
+*          (index.json, following nested indexes).
+* Parameters: dir string (extracted archive)
+* Output: []string (layer files, bottom first), string (image name), error
+*************************************/
+func imageLayers(dir string) ([]string, string, error) {
+	if s, err := readFileContent(filepath.Join(dir, "manifest.json")); err == nil {
+		var saved []struct {
+			RepoTags []string `json:"RepoTags"`
+			Layers   []string `json:"Layers"`
+		}
+		if err := json.Unmarshal([]byte(s), &saved); err == nil && len(saved) > 0 {
+			var layers []string
+			for _, l := range saved[0].Layers {
+				layers = append(layers, safeJoin(dir, l))
+			}
+			name := ""
+			if len(saved[0].RepoTags) > 0 {
+				name = saved[0].RepoTags[0]
+			}
+			return layers, name, nil
+		}
+	}
+
+	blob := func(digest string) string {
+		return safeJoin(dir, "blobs/"+strings.Replace(digest, ":", "/", 1))
+	}
+	s, err := readFileContent(filepath.Join(dir, "index.json"))
+	if err != nil {
+		return nil, "", fmt.Errorf("neither manifest.json nor index.json found: not a docker save or OCI archive")
+	}
+	name := ""
+	for depth := 0; depth < 4; depth++ {
+		var m imageManifest
+		if err := json.Unmarshal([]byte(s), &m); err != nil {
+			return nil, "", fmt.Errorf("invalid image manifest: %v", err)
+		}
+		if len(m.Layers) > 0 {
+			var layers []string
+			for _, l := range m.Layers {
+				layers = append(layers, blob(l.Digest))
+			}
+			return layers, name, nil
+		}
+		d, ok := pickManifest(m.Manifests)
+		if !ok {
+			break
+		}
+		if name == "" {
+			name = d.Annotations["io.containerd.image.name"]
+		}
+		if name == "" {
+			name = d.Annotations["org.opencontainers.image.ref.name"]
+		}
+		if s, err = readFileContent(blob(d.Digest)); err != nil {
+			return nil, "", err
+		}
+	}
+	return nil, "", fmt.Errorf("no image manifest with layers found")
+}
+
+/************************************
+* Function Name: analyzeImage
+* Purpose: Inventory a container image saved as a tarball (docker save or OCI
+*          layout) without a daemon: the layers are applied in order into a
+*          temporary root filesystem, which is then scanned like a repository
+*          (OS package databases and every language detector, limited to
+*          allowedSet when -langs is given). At most imageMaxDiskSize bytes
+*          are written to the temporary directory.
+* Parameters: file string, allowedSet map[string]bool
+* Output: Analysis, error
+*************************************/
+func analyzeImage(file string, allowedSet map[string]bool) (Analysis, error) {
+	tmp, err := os.MkdirTemp("", "sca-image-")
+	if err != nil {
+		return Analysis{}, err
+	}
+	defer removePath(tmp)
+
+	archive := filepath.Join(tmp, "archive")
+	rootfs := filepath.Join(tmp, "rootfs")
+	if err := os.MkdirAll(rootfs, 0o755); err != nil {
+		return Analysis{}, err
+	}
+	r, closeArchive, err := openDecompressed(file)
+	if err != nil {
+		return Analysis{}, err
+	}
+	budget := int64(imageMaxDiskSize)
+	err = extractTar(r, archive, &budget)
+	closeArchive()
+	if err != nil {
+		return Analysis{}, fmt.Errorf("%s: %v", file, err)
+	}
+
+	layers, name, err := imageLayers(archive)
+	if err != nil {
+		return Analysis{}, fmt.Errorf("%s: %v", file, err)
+	}
+	for _, layer := range layers {
+		if err := applyLayer(layer, rootfs, &budget); err != nil {
+			return Analysis{}, err
+		}
+	}
+
+	managers, err := detectPackageManagers(rootfs, nil)
+	if err != nil {
+		return Analysis{}, err
+	}
+	a := analyzeRepository("", rootfs, filterManagers(managers, allowedSet))
+	a.Repo = name
+	if a.Repo == "" {
+		a.Repo = filepath.Base(file)
+	}
+	return a, nil
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
 	"strings"
 )
 
@@ -17,13 +18,30 @@ func main() {
 	var outputFmt string
 	var outputFile string
 	var allowedLangs string
+	var submodules bool
+	var fingerprintsPath string
+	var binaryPath string
+	var archivePath string
+	var sha1IndexPath string
+	var imagePath string
+	var sourcePath string
+	var sbomPaths string
 
 	flag.StringVar(&repoURL, "repo", "", "git repository URL to clone")
 	flag.StringVar(&targetDir, "dir", "./repo", "target directory for the repository")
 	flag.BoolVar(&skipCloneFlag, "skip-clone", false, "skip cloning and analyze existing directory")
-	flag.StringVar(&outputFmt, "output", "cli", "output format: cli or json")
-	flag.StringVar(&outputFile, "o", "", "filepath to write JSON output (must end in .json)")
+	flag.StringVar(&outputFmt, "output", "cli", "output format: cli, json, cyclonedx-json, cyclonedx-xml, spdx-json or spdx-tv")
+	flag.StringVar(&outputFile, "o", "", "filepath to write the output to (must end in .json, .xml for cyclonedx-xml or .spdx for spdx-tv)")
 	flag.StringVar(&allowedLangs, "langs", "", "comma-separated list of languages to include (e.g., Go,Python,Node)")
+	flag.BoolVar(&submodules, "submodules", false, "clone git submodules and scan their contents")
+	flag.StringVar(&fingerprintsPath, "fingerprints", "", "offline fingerprint database (JSON) used to identify copied library files")
+	flag.StringVar(&binaryPath, "binary", "", "scan a compiled Go binary (or a directory of binaries) instead of a repository")
+	flag.StringVar(&archivePath, "archive", "", "scan a JAR/WAR/EAR (or a directory of them) instead of a repository")
+	flag.StringVar(&sha1IndexPath, "sha1-index", "", "local index of jar SHA-1 checksums to group:artifact:version lines")
+	flag.StringVar(&imagePath, "image", "", "scan a container image tarball (docker save or OCI layout) instead of a repository")
+	flag.StringVar(&sourcePath, "source", "", "scan a source archive (zip, tar, tar.gz, tar.bz2, tar.xz, tar.zst) without unpacking it")
+	flag.StringVar(&sbomPaths, "sbom", "", "comma-separated CycloneDX or SPDX documents to read; merged into the scan, or reported alone without a repository")
+	flag.StringVar(&cyclonedxVersion, "cyclonedx-version", cyclonedxVersion, "CycloneDX specification version for cyclonedx-* output: 1.5 or 1.6")
 	flag.Parse()
 
 	// allow positional first arg as repo URL
@@ -62,11 +80,97 @@ func main() {
 	}
 
 	// Validate output file extension
-	if outputFile != "" && !strings.HasSuffix(strings.ToLower(outputFile), ".json") {
-		fmt.Println("Error: Output file must have .json extension")
+	wantExt := ".json"
+	switch strings.ToLower(outputFmt) {
+	case "cyclonedx-xml":
+		wantExt = ".xml"
+	case "spdx-tv":
+		wantExt = ".spdx"
+	}
+	if outputFile != "" && !strings.HasSuffix(strings.ToLower(outputFile), wantExt) {
+		fmt.Printf("Error: Output file must have %s extension\n", wantExt)
 		os.Exit(1)
 	}
 
+	if sha1IndexPath != "" {
+		index, err := loadSHA1Index(sha1IndexPath)
+		if err != nil {
+			log.Fatalf("failed to load SHA-1 index: %v", err)
+		}
+		jarSHA1Index = index
+	}
+
+	if fingerprintsPath != "" {
+		db, err := loadFingerprintDB(fingerprintsPath)
+		if err != nil {
+			log.Fatalf("failed to load fingerprint database: %v", err)
+		}
+		fingerprintDB = db
+	}
+
+	// existing SBOMs (vendor components) are merged into whatever is scanned
+	var sboms []Analysis
+	for _, file := range strings.Split(sbomPaths, ",") {
+		if file = strings.TrimSpace(file); file == "" {
+			continue
+		}
+		a, err := analyzeSBOM(file)
+		if err != nil {
+			log.Fatalf("cannot read SBOM: %v", err)
+		}
+		sboms = append(sboms, a)
+	}
+
+	if imagePath != "" {
+		analysis, err := analyzeImage(imagePath, allowedSet)
+		if err != nil {
+			log.Fatalf("image scan failed: %v", err)
+		}
+		writeAnalysis(mergeAnalyses(analysis, sboms...), outputFmt, outputFile)
+		return
+	}
+
+	if archivePath != "" {
+		analysis, err := analyzeArchives(archivePath)
+		if err != nil {
+			log.Fatalf("archive scan failed: %v", err)
+		}
+		writeAnalysis(mergeAnalyses(analysis, sboms...), outputFmt, outputFile)
+		return
+	}
+
+	if binaryPath != "" {
+		analysis, err := analyzeBinaries(binaryPath)
+		if err != nil {
+			log.Fatalf("binary scan failed: %v", err)
+		}
+		writeAnalysis(mergeAnalyses(analysis, sboms...), outputFmt, outputFile)
+		return
+	}
+
+	if sourcePath != "" {
+		fsys, closeSource, err := openSourceArchive(sourcePath)
+		if err != nil {
+			log.Fatalf("cannot open source archive: %v", err)
+		}
+		defer closeSource()
+		// the archive root becomes the scanned tree
+		sourceFS = fsys
+		repoURL, targetDir, skipCloneFlag = "", ".", true
+	}
+
+	// SBOMs are reported alone unless there is a checkout to scan: -dir given and present
+	dirGiven := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "dir" {
+			dirGiven = true
+		}
+	})
+	if len(sboms) > 0 && repoURL == "" && sourcePath == "" && (!dirGiven || !pathExists(targetDir)) {
+		writeAnalysis(mergeAnalyses(sboms[0], sboms[1:]...), outputFmt, outputFile)
+		return
+	}
+
 	if repoURL == "" && (targetDir == "" || !pathExists(targetDir)) {
 		fmt.Println("Usage: sca-cli <git-url> [-dir <path>] [-o filepath.json] [--langs Go,Python,...] or point -dir to an existing checkout")
 		os.Exit(1)
@@ -80,43 +184,66 @@ func main() {
 			}
 		}
 		log.Printf("cloning %s -> %s\n", repoURL, targetDir)
-		if err := cloneRepository(repoURL, targetDir); err != nil {
+		if err := cloneRepository(repoURL, targetDir, submodules); err != nil {
 			log.Fatalf("git clone failed: %v", err)
 		}
 	}
 
-	managers, err := detectPackageManagers(targetDir)
+	// submodules are listed as dependencies; their contents are only scanned on request
+	var skip []string
+	if !submodules {
+		skip = submoduleDirs(targetDir)
+	}
+	managers, err := detectPackageManagers(targetDir, skip)
 	if err != nil {
 		log.Fatalf("detection failed: %v", err)
 	}
 
-	// Filter managers based on allowed languages
-	if len(allowedSet) > 0 {
-		filteredManagers := make(map[string][]string)
-		for key, files := range managers {
-			normalized := normalizeLangKey(key)
-			if allowedSet[normalized] {
-				filteredManagers[key] = files
-			}
-		}
-		managers = filteredManagers
-	}
+	managers = filterManagers(managers, allowedSet)
 
 	analysis := analyzeRepository(repoURL, targetDir, managers)
+	if sourcePath != "" {
+		analysis.Repo = filepath.Base(sourcePath)
+	} else {
+		analysis.Commit, analysis.VCS = checkoutInfo(targetDir)
+		if repoURL != "" {
+			analysis.VCS = repoURL
+		}
+	}
+	writeAnalysis(mergeAnalyses(analysis, sboms...), outputFmt, outputFile)
+}
 
-	if strings.ToLower(outputFmt) == "json" || outputFile != "" {
+/************************************
+* Function Name: writeAnalysis
+* Purpose: Print an Analysis as JSON (to stdout or the -o file) or as the
+*          pretty CLI report.
+* Parameters: analysis Analysis, outputFmt string, outputFile string
+* Output: none
+*************************************/
+func writeAnalysis(analysis Analysis, outputFmt, outputFile string) {
+	format := strings.ToLower(outputFmt)
+	if format == "cyclonedx-json" || format == "cyclonedx-xml" {
+		enc, err := cyclonedxBOM(analysis, cyclonedxVersion, format == "cyclonedx-xml")
+		if err != nil {
+			log.Fatalf("failed to build CycloneDX SBOM: %v", err)
+		}
+		writeOutput(enc, outputFile, "CycloneDX SBOM")
+		return
+	}
+	if format == "spdx-json" || format == "spdx-tv" {
+		enc, err := spdxBOM(analysis, format == "spdx-tv")
+		if err != nil {
+			log.Fatalf("failed to build SPDX document: %v", err)
+		}
+		writeOutput(enc, outputFile, "SPDX document")
+		return
+	}
+	if format == "json" || outputFile != "" {
 		enc, err := json.MarshalIndent(analysis, "", "  ")
 		if err != nil {
 			log.Fatalf("failed to marshal json: %v", err)
 		}
-		if outputFile != "" {
-			if err := os.WriteFile(outputFile, enc, 0644); err != nil {
-				log.Fatalf("failed to write output file: %v", err)
-			}
-			fmt.Printf("Wrote JSON to %s\n", outputFile)
-		} else {
-			fmt.Println(string(enc))
-		}
+		writeOutput(enc, outputFile, "JSON")
 		return
 	}
 
@@ -131,17 +258,40 @@ func main() {
 	for _, f := range analysis.Files {
 		fmt.Printf("  - %s\n", f)
 	}
+	if len(analysis.Vendored) > 0 {
+		fmt.Printf("\nVendored (third-party code):\n")
+		for _, d := range analysis.Vendored {
+			fmt.Printf("  - %s\n", d)
+		}
+	}
 	fmt.Println()
 	printFooter()
 }
 
+/************************************
+* Function Name: writeOutput
+* Purpose: Write a rendered document to the -o file, or to stdout.
+* Parameters: enc []byte, outputFile string, what string (shown in the message)
+* Output: none
+*************************************/
+func writeOutput(enc []byte, outputFile, what string) {
+	if outputFile == "" {
+		fmt.Println(string(enc))
+		return
+	}
+	if err := os.WriteFile(outputFile, enc, 0644); err != nil {
+		log.Fatalf("failed to write output file: %v", err)
+	}
+	fmt.Printf("Wrote %s to %s\n", what, outputFile)
+}
+
 // normalizeLangName normalizes user input language names to match detection keys
 func normalizeLangName(lang string) string {
 	lower := strings.ToLower(strings.TrimSpace(lang))
 	switch lower {
 	case "go", "golang":
 		return "go"
-	case "node", "nodejs", "npm", "javascript", "js":
+	case "node", "nodejs", "npm", "javascript", "js", "bun":
 		return "node"
 	case "yarn":
 		return "yarn"
@@ -159,21 +309,93 @@ func normalizeLangName(lang string) string {
 		return "rust"
 	case "swift":
 		return "swift"
+	case "cocoapods", "pods", "pod":
+		return "cocoapods"
+	case "carthage":
+		return "carthage"
+	case "nuget", "dotnet", ".net", "csharp", "c#", "fsharp", "f#":
+		return "nuget"
+	case "dart", "flutter", "pub":
+		return "dart"
+	case "hex", "elixir", "erlang", "mix", "rebar":
+		return "hex"
+	case "conan":
+		return "conan"
+	case "vcpkg":
+		return "vcpkg"
+	case "cmake":
+		return "cmake"
+	case "sbt", "scala":
+		return "sbt"
+	case "clojure", "clj", "leiningen", "lein":
+		return "clojure"
+	case "bazel":
+		return "bazel"
+	case "cran", "r":
+		return "cran"
+	case "julia", "jl":
+		return "julia"
+	case "hackage", "haskell", "hs", "cabal", "stack":
+		return "hackage"
+	case "conda", "pixi", "anaconda":
+		return "conda"
+	case "deno", "jsr":
+		return "deno"
+	case "docker", "dockerfile", "containerfile", "compose", "oci", "container", "kubernetes", "k8s", "images", "system":
+		return "docker"
+	case "github-actions", "githubactions", "actions", "gha", "workflows":
+		return "github-actions"
+	case "terraform", "tf", "hcl", "opentofu":
+		return "terraform"
+	case "helm", "chart", "charts":
+		return "helm"
+	case "git-submodule", "submodule", "submodules", "git":
+		return "git-submodule"
+	case "jar", "war", "ear", "java-archive":
+		return "java-archive"
+	case "dpkg", "deb", "debian", "ubuntu":
+		return "dpkg"
+	case "apk", "alpine":
+		return "apk"
+	case "rpm", "yum", "dnf", "fedora", "rhel":
+		return "rpm"
 	default:
 		return lower
 	}
 }
 
+/************************************
+* Function Name: filterManagers
+* Purpose: Keep the detected package managers whose language is in the
+*          -langs set; an empty set keeps them all. Fingerprint matches are
+*          only detected when -fingerprints is given and are always kept.
+* Parameters: managers map[string][]string, allowedSet map[string]bool
+* Output: map[string][]string
+*************************************/
+func filterManagers(managers map[string][]string, allowedSet map[string]bool) map[string][]string {
+	if len(allowedSet) == 0 {
+		return managers
+	}
+	filteredManagers := make(map[string][]string)
+	for key, files := range managers {
+		normalized := normalizeLangKey(key)
+		if allowedSet[normalized] || key == "fingerprint" {
+			filteredManagers[key] = files
+		}
+	}
+	return filteredManagers
+}
+
 // normalizeLangKey normalizes detection keys to match user input
 func normalizeLangKey(key string) string {
 	switch key {
-	case "go":
+	case "go", "go/vendor":
 		return "go"
-	case "node/npm":
+	case "node/npm", "node/installed":
 		return "node"
 	case "node/yarn":
 		return "yarn"
-	case "python":
+	case "python", "python/installed":
 		return "python"
 	case "maven":
 		return "maven"
@@ -181,12 +403,58 @@ func normalizeLangKey(key string) string {
 		return "gradle"
 	case "composer/php":
 		return "composer"
-	case "ruby":
+	case "ruby", "ruby/installed":
 		return "ruby"
 	case "rust":
 		return "rust"
 	case "swift":
 		return "swift"
+	case "cocoapods":
+		return "cocoapods"
+	case "carthage":
+		return "carthage"
+	case "nuget":
+		return "nuget"
+	case "dart":
+		return "dart"
+	case "hex":
+		return "hex"
+	case "conan":
+		return "conan"
+	case "vcpkg":
+		return "vcpkg"
+	case "cmake":
+		return "cmake"
+	case "sbt":
+		return "sbt"
+	case "clojure":
+		return "clojure"
+	case "bazel":
+		return "bazel"
+	case "cran":
+		return "cran"
+	case "julia":
+		return "julia"
+	case "hackage":
+		return "hackage"
+	case "conda":
+		return "conda"
+	case "deno":
+		return "deno"
+	case "docker":
+		return "docker"
+	case "github-actions":
+		return "github-actions"
+	case "terraform":
+		return "terraform"
+	case "helm":
+		return "helm"
+	case "git-submodule":
+		return "git-submodule"
+	case "java-archive":
+		return "java-archive"
+	case "dpkg", "apk", "rpm":
+		return key
 	default:
 		return key
 	}
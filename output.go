@@ -1,7 +1,10 @@
 package main
 
 import (
+	"crypto/sha1"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"path/filepath"
 	"sort"
 	"strings"
@@ -15,6 +18,13 @@ type Analysis struct {
 	Type         []string                       `json:"type"`
 	Dependencies map[string]map[string][]string `json:"dependencies"`
 	Files        []string                       `json:"files"`
+	Purls        []string                       `json:"purls,omitempty"`
+	Vendored     []string                       `json:"vendored,omitempty"`
+	Commit       string                         `json:"commit,omitempty"`
+	VCS          string                         `json:"vcs,omitempty"` // repository URL of the checkout
+
+	fileSHA1  map[string]string              // SHA-1 of the regular files in Files, for SBOM output
+	dependsOn map[string]map[string][]string // ecosystem -> name@version -> its dependencies, from lockfiles
 }
 
 /************************************
@@ -27,17 +37,21 @@ func analyzeRepository(repoURL, root string, managers map[string][]string) Analy
 	var a Analysis
 	if repoURL != "" {
 		a.Repo = repoURL
+	} else if abs, err := filepath.Abs(root); err == nil {
+		a.Repo = filepath.Base(abs) // "-dir ." names the working directory
 	} else {
 		a.Repo = filepath.Base(root)
 	}
 
 	// Types
-	types := make([]string, 0, len(managers))
+	typeSet := map[string]struct{}{}
 	for k := range managers {
-		types = append(types, niceName(k))
+		if k == "fingerprint" {
+			continue // matches are typed by the ecosystem they belong to
+		}
+		typeSet[niceName(k)] = struct{}{}
 	}
-	sort.Strings(types)
-	a.Type = types
+	a.Type = setToSortedSlice(typeSet)
 
 	// Files
 	fileSet := map[string]struct{}{}
@@ -55,6 +69,9 @@ func analyzeRepository(repoURL, root string, managers map[string][]string) Analy
 	}
 	sort.Strings(a.Files)
 
+	// Vendored third-party directories
+	a.Vendored = findVendoredDirs(root)
+
 	// Dependencies per ecosystem -> file -> deps
 	a.Dependencies = map[string]map[string][]string{}
 	for k, paths := range managers {
@@ -75,7 +92,14 @@ func analyzeRepository(repoURL, root string, managers map[string][]string) Analy
 				if err != nil {
 					rel = p
 				}
-				perFile[rel] = parsePackageJSONDeps(p)
+				switch strings.ToLower(filepath.Base(p)) {
+				case "bun.lock":
+					perFile[rel] = parseBunLockDeps(p)
+				case "pnpm-workspace.yaml":
+					perFile[rel] = parsePnpmWorkspaceDeps(p)
+				default:
+					perFile[rel] = parsePackageJSONDeps(p, root)
+				}
 			}
 		case "maven":
 			for _, p := range paths {
@@ -126,7 +150,11 @@ func analyzeRepository(repoURL, root string, managers map[string][]string) Analy
 				if err != nil {
 					rel = p
 				}
-				perFile[rel] = parsePackageSwiftDeps(p)
+				if strings.EqualFold(filepath.Base(p), "Package.resolved") {
+					perFile[rel] = parsePackageResolvedDeps(p)
+				} else {
+					perFile[rel] = parsePackageSwiftDeps(p)
+				}
 			}
 		case "ruby":
 			for _, p := range paths {
@@ -136,6 +164,335 @@ func analyzeRepository(repoURL, root string, managers map[string][]string) Analy
 				}
 				perFile[rel] = parseGemfileDeps(p)
 			}
+		case "cocoapods":
+			for _, p := range paths {
+				rel, err := filepath.Rel(root, p)
+				if err != nil {
+					rel = p
+				}
+				if strings.EqualFold(filepath.Base(p), "Podfile.lock") {
+					perFile[rel] = parsePodfileLockDeps(p)
+				} else {
+					perFile[rel] = parsePodfileDeps(p)
+				}
+			}
+		case "carthage":
+			for _, p := range paths {
+				rel, err := filepath.Rel(root, p)
+				if err != nil {
+					rel = p
+				}
+				perFile[rel] = parseCartfileDeps(p)
+			}
+		case "nuget":
+			for _, p := range paths {
+				rel, err := filepath.Rel(root, p)
+				if err != nil {
+					rel = p
+				}
+				switch strings.ToLower(filepath.Base(p)) {
+				case "directory.packages.props":
+					perFile[rel] = parseDirectoryPackagesPropsDeps(p, root)
+				case "packages.config":
+					perFile[rel] = parsePackagesConfigDeps(p)
+				case "packages.lock.json":
+					perFile[rel] = parseNuGetLockDeps(p)
+				default:
+					perFile[rel] = parsePackageReferenceDeps(p, root)
+				}
+			}
+		case "dart":
+			for _, p := range paths {
+				rel, err := filepath.Rel(root, p)
+				if err != nil {
+					rel = p
+				}
+				if strings.EqualFold(filepath.Base(p), "pubspec.lock") {
+					perFile[rel] = parsePubspecLockDeps(p)
+				} else {
+					perFile[rel] = parsePubspecDeps(p)
+				}
+			}
+		case "hex":
+			for _, p := range paths {
+				rel, err := filepath.Rel(root, p)
+				if err != nil {
+					rel = p
+				}
+				switch strings.ToLower(filepath.Base(p)) {
+				case "mix.lock":
+					perFile[rel] = parseMixLockDeps(p)
+				case "rebar.config":
+					perFile[rel] = parseRebarConfigDeps(p)
+				case "rebar.lock":
+					perFile[rel] = parseRebarLockDeps(p)
+				default:
+					perFile[rel] = parseMixExsDeps(p)
+				}
+			}
+		case "conan":
+			for _, p := range paths {
+				rel, err := filepath.Rel(root, p)
+				if err != nil {
+					rel = p
+				}
+				switch strings.ToLower(filepath.Base(p)) {
+				case "conan.lock":
+					perFile[rel] = parseConanLockDeps(p)
+				case "conanfile.py":
+					perFile[rel] = parseConanfilePyDeps(p)
+				default:
+					perFile[rel] = parseConanfileTxtDeps(p)
+				}
+			}
+		case "vcpkg":
+			for _, p := range paths {
+				rel, err := filepath.Rel(root, p)
+				if err != nil {
+					rel = p
+				}
+				perFile[rel] = parseVcpkgJSONDeps(p)
+			}
+		case "cmake":
+			for _, p := range paths {
+				rel, err := filepath.Rel(root, p)
+				if err != nil {
+					rel = p
+				}
+				perFile[rel] = parseCMakeListsDeps(p)
+			}
+		case "sbt":
+			for _, p := range paths {
+				rel, err := filepath.Rel(root, p)
+				if err != nil {
+					rel = p
+				}
+				perFile[rel] = parseSbtDeps(p)
+			}
+		case "clojure":
+			for _, p := range paths {
+				rel, err := filepath.Rel(root, p)
+				if err != nil {
+					rel = p
+				}
+				if strings.EqualFold(filepath.Base(p), "deps.edn") {
+					perFile[rel] = parseDepsEdnDeps(p)
+				} else {
+					perFile[rel] = parseProjectCljDeps(p)
+				}
+			}
+		case "bazel":
+			for _, p := range paths {
+				rel, err := filepath.Rel(root, p)
+				if err != nil {
+					rel = p
+				}
+				if strings.EqualFold(filepath.Base(p), "MODULE.bazel") {
+					perFile[rel] = parseModuleBazelDeps(p)
+				} else {
+					perFile[rel] = parseMavenInstallJSONDeps(p)
+				}
+			}
+		case "cran":
+			for _, p := range paths {
+				rel, err := filepath.Rel(root, p)
+				if err != nil {
+					rel = p
+				}
+				if strings.EqualFold(filepath.Base(p), "renv.lock") {
+					perFile[rel] = parseRenvLockDeps(p)
+				} else {
+					perFile[rel] = parseDescriptionDeps(p)
+				}
+			}
+		case "julia":
+			for _, p := range paths {
+				rel, err := filepath.Rel(root, p)
+				if err != nil {
+					rel = p
+				}
+				if strings.Contains(strings.ToLower(filepath.Base(p)), "manifest") {
+					perFile[rel] = parseJuliaManifestDeps(p)
+				} else {
+					perFile[rel] = parseJuliaProjectDeps(p)
+				}
+			}
+		case "hackage":
+			for _, p := range paths {
+				rel, err := filepath.Rel(root, p)
+				if err != nil {
+					rel = p
+				}
+				switch strings.ToLower(filepath.Base(p)) {
+				case "stack.yaml":
+					perFile[rel] = parseStackYamlDeps(p)
+				case "cabal.project.freeze":
+					perFile[rel] = parseCabalFreezeDeps(p)
+				default:
+					perFile[rel] = parseCabalFileDeps(p)
+				}
+			}
+		case "conda":
+			for _, p := range paths {
+				rel, err := filepath.Rel(root, p)
+				if err != nil {
+					rel = p
+				}
+				switch name := strings.ToLower(filepath.Base(p)); {
+				case strings.HasPrefix(name, "conda-lock."):
+					perFile[rel] = parseCondaLockDeps(p)
+				case name == "pixi.toml":
+					perFile[rel] = parsePixiTomlDeps(p)
+				case name == "pixi.lock":
+					perFile[rel] = parsePixiLockDeps(p)
+				default:
+					perFile[rel] = parseCondaEnvironmentDeps(p)
+				}
+			}
+		case "docker":
+			for _, p := range paths {
+				rel, err := filepath.Rel(root, p)
+				if err != nil {
+					rel = p
+				}
+				name := strings.ToLower(filepath.Base(p))
+				switch {
+				case isHelmValuesFile(p):
+					perFile[rel] = parseHelmValuesDeps(p)
+				case strings.Contains(name, "compose") && (strings.HasSuffix(name, ".yml") || strings.HasSuffix(name, ".yaml")):
+					perFile[rel] = parseComposeDeps(p)
+				case strings.HasSuffix(name, ".yml") || strings.HasSuffix(name, ".yaml"):
+					perFile[rel] = parseKubernetesManifestDeps(p)
+				default:
+					images, installs := splitSystemInstalls(parseDockerfileDeps(p))
+					perFile[rel] = images
+					if len(installs) > 0 {
+						if _, exists := a.Dependencies["System"]; !exists {
+							a.Dependencies["System"] = map[string][]string{}
+						}
+						a.Dependencies["System"][rel] = installs
+					}
+				}
+			}
+		case "github-actions":
+			for _, p := range paths {
+				rel, err := filepath.Rel(root, p)
+				if err != nil {
+					rel = p
+				}
+				perFile[rel] = parseWorkflowDeps(p)
+			}
+		case "fingerprint":
+			for _, p := range paths {
+				rel, err := filepath.Rel(root, p)
+				if err != nil {
+					rel = p
+				}
+				other, dep := parseFingerprintDeps(p)
+				if dep == "" {
+					continue
+				}
+				if _, exists := a.Dependencies[other]; !exists {
+					a.Dependencies[other] = map[string][]string{}
+				}
+				a.Dependencies[other][rel] = append(a.Dependencies[other][rel], dep)
+			}
+			continue
+		case "node/installed", "python/installed", "ruby/installed", "go/vendor":
+			for _, p := range paths {
+				rel, err := filepath.Rel(root, p)
+				if err != nil {
+					rel = p
+				}
+				switch k {
+				case "node/installed":
+					perFile[rel] = parseNodeModulesDeps(p)
+				case "python/installed":
+					perFile[rel] = parseSitePackagesDeps(p)
+				case "ruby/installed":
+					perFile[rel] = parseGemSpecificationsDeps(p)
+				default:
+					perFile[rel] = parseVendorModulesDeps(p)
+				}
+			}
+		case "dpkg", "apk", "rpm":
+			for _, p := range paths {
+				rel, err := filepath.Rel(root, p)
+				if err != nil {
+					rel = p
+				}
+				switch k {
+				case "dpkg":
+					perFile[rel] = parseDpkgStatusDeps(p)
+				case "apk":
+					perFile[rel] = parseApkInstalledDeps(p)
+				default:
+					perFile[rel] = parseRpmDBDeps(p)
+				}
+			}
+		case "java-archive":
+			for _, p := range paths {
+				rel, err := filepath.Rel(root, p)
+				if err != nil {
+					rel = p
+				}
+				perFile[rel] = parseJavaArchiveDeps(p)
+			}
+		case "git-submodule":
+			for _, p := range paths {
+				rel, err := filepath.Rel(root, p)
+				if err != nil {
+					rel = p
+				}
+				perFile[rel] = parseGitmodulesDeps(p)
+			}
+		case "helm":
+			for _, p := range paths {
+				rel, err := filepath.Rel(root, p)
+				if err != nil {
+					rel = p
+				}
+				perFile[rel] = parseChartDeps(p)
+			}
+		case "terraform":
+			for _, p := range paths {
+				rel, err := filepath.Rel(root, p)
+				if err != nil {
+					rel = p
+				}
+				if strings.EqualFold(filepath.Base(p), ".terraform.lock.hcl") {
+					perFile[rel] = parseTerraformLockDeps(p)
+				} else {
+					perFile[rel] = parseTerraformDeps(p)
+				}
+			}
+		case "deno":
+			for _, p := range paths {
+				rel, err := filepath.Rel(root, p)
+				if err != nil {
+					rel = p
+				}
+				var byEco map[string][]string
+				if strings.ToLower(filepath.Base(p)) == "deno.lock" {
+					byEco = parseDenoLockDeps(p)
+				} else {
+					byEco = parseDenoJSONDeps(p)
+				}
+				perFile[rel] = byEco[eco]
+				if perFile[rel] == nil {
+					perFile[rel] = []string{}
+				}
+				// npm: and jsr: specifiers are reported under their own ecosystems
+				for other, deps := range byEco {
+					if other == eco {
+						continue
+					}
+					if _, exists := a.Dependencies[other]; !exists {
+						a.Dependencies[other] = map[string][]string{}
+					}
+					a.Dependencies[other][rel] = append(a.Dependencies[other][rel], deps...)
+				}
+			}
 		default:
 			for _, p := range paths {
 				rel, err := filepath.Rel(root, p)
@@ -153,9 +510,116 @@ func analyzeRepository(repoURL, root string, managers map[string][]string) Analy
 		}
 	}
 
+	// Ecosystems reached only through another manager (npm: specifiers in deno.json, ...)
+	seen := map[string]struct{}{}
+	for _, t := range a.Type {
+		seen[t] = struct{}{}
+	}
+	for eco := range a.Dependencies {
+		if _, ok := seen[eco]; !ok {
+			a.Type = append(a.Type, eco)
+		}
+	}
+	sort.Strings(a.Type)
+
+	// Dependencies declared inside vendored code belong to the third party
+	for _, perFile := range a.Dependencies {
+		for file, deps := range perFile {
+			if vendoredDir(file, a.Vendored) == "" {
+				continue
+			}
+			for i, dep := range deps {
+				deps[i] = annotateDep(dep, "vendored")
+			}
+		}
+	}
+
+	// Package URLs for every registry dependency
+	a.Purls = collectPurls(a.Dependencies)
+	a.fileSHA1 = fileChecksums(root, a.Files)
+	a.dependsOn = lockfileEdges(root, a.Dependencies)
+
 	return a
 }
 
+/************************************
+* Function Name: fileChecksums
+* Purpose: Compute the SHA-1 of each regular file of an analysis, relative to
+*          the scanned root. Directories (node_modules, site-packages, ...) and
+*          unreadable files are left out.
+* Parameters: root string, files []string
+* Output: map[string]string (file -> hex digest)
+*************************************/
+func fileChecksums(root string, files []string) map[string]string {
+	sums := map[string]string{}
+	for _, f := range files {
+		fh, err := sourceFS.Open(filepath.ToSlash(filepath.Join(root, f)))
+		if err != nil {
+			continue
+		}
+		h := sha1.New()
+		if st, err := fh.Stat(); err == nil && st.Mode().IsRegular() {
+			if _, err := io.Copy(h, fh); err == nil {
+				sums[f] = hex.EncodeToString(h.Sum(nil))
+			}
+		}
+		fh.Close()
+	}
+	return sums
+}
+
+/************************************
+* Function Name: lockfileEdges
+* Purpose: Collect the package-to-package edges recorded by the lockfiles of
+*          an analysis (Podfile.lock, bun.lock) for the SBOM dependency graph.
+* Parameters: root string, deps map[string]map[string][]string (ecosystem -> file -> deps)
+* Output: map[string]map[string][]string (ecosystem -> name@version -> dependencies)
+*************************************/
+func lockfileEdges(root string, deps map[string]map[string][]string) map[string]map[string][]string {
+	out := map[string]map[string][]string{}
+	for eco, perFile := range deps {
+		for file := range perFile {
+			var edges map[string][]string
+			switch strings.ToLower(filepath.Base(file)) {
+			case "podfile.lock":
+				edges = podfileLockEdges(filepath.Join(root, file))
+			case "bun.lock":
+				edges = bunLockEdges(filepath.Join(root, file))
+			}
+			if len(edges) == 0 {
+				continue
+			}
+			if out[eco] == nil {
+				out[eco] = map[string][]string{}
+			}
+			for parent, children := range edges {
+				out[eco][parent] = append(out[eco][parent], children...)
+			}
+		}
+	}
+	return out
+}
+
+/************************************
+* Function Name: collectPurls
+* Purpose: Compute the sorted, de-duplicated package URLs of all dependencies.
+* Parameters: deps map[string]map[string][]string (ecosystem -> file -> deps)
+* Output: []string
+*************************************/
+func collectPurls(deps map[string]map[string][]string) []string {
+	purlSet := map[string]struct{}{}
+	for eco, perFile := range deps {
+		for _, list := range perFile {
+			for _, dep := range list {
+				if purl := packageURL(eco, dep); purl != "" {
+					purlSet[purl] = struct{}{}
+				}
+			}
+		}
+	}
+	return setToSortedSlice(purlSet)
+}
+
 /************************************
 * Pretty printing helpers
 *************************************/
@@ -19,6 +19,45 @@ func setToSortedSlice(s map[string]struct{}) []string {
 	return list
 }
 
+/************************************
+* Function Name: annotateDep
+* Purpose: Append notes to a dependency entry, e.g. "name@1.0 (revision: abc)".
+*          Empty notes are dropped; with no notes the entry is returned as-is.
+*          Notes are merged into an entry that is already annotated.
+* Parameters: dep string, notes ...string
+* Output: string
+*************************************/
+func annotateDep(dep string, notes ...string) string {
+	kept := make([]string, 0, len(notes))
+	for _, n := range notes {
+		if n != "" {
+			kept = append(kept, n)
+		}
+	}
+	if len(kept) == 0 {
+		return dep
+	}
+	if strings.Contains(dep, " (") && strings.HasSuffix(dep, ")") {
+		return fmt.Sprintf("%s, %s)", dep[:len(dep)-1], strings.Join(kept, ", "))
+	}
+	return fmt.Sprintf("%s (%s)", dep, strings.Join(kept, ", "))
+}
+
+/************************************
+* Function Name: licenseNote
+* Purpose: Format a declared license (SPDX expression or free text) as a note.
+*          Commas separate notes, so they are replaced by spaces.
+* Parameters: license string
+* Output: string (empty when no license is declared)
+*************************************/
+func licenseNote(license string) string {
+	license = strings.Join(strings.Fields(strings.ReplaceAll(license, ",", " ")), " ")
+	if license == "" {
+		return ""
+	}
+	return "license: " + license
+}
+
 /************************************
 * Function Name: readFileContent
 * Purpose: Read a file and return its contents as a string.
@@ -26,7 +65,7 @@ func setToSortedSlice(s map[string]struct{}) []string {
 * Output: string, error
 *************************************/
 func readFileContent(path string) (string, error) {
-	b, err := os.ReadFile(path)
+	b, err := readSourceFile(path)
 	if err != nil {
 		return "", err
 	}
@@ -35,13 +74,13 @@ func readFileContent(path string) (string, error) {
 
 func niceName(key string) string {
 	switch key {
-	case "go":
+	case "go", "go/vendor":
 		return "Go"
-	case "node/npm":
+	case "node/npm", "node/installed":
 		return "Node"
 	case "node/yarn":
 		return "Yarn"
-	case "python":
+	case "python", "python/installed":
 		return "Python"
 	case "maven":
 		return "Maven"
@@ -49,12 +88,62 @@ func niceName(key string) string {
 		return "Gradle"
 	case "composer/php":
 		return "Composer"
-	case "ruby":
+	case "ruby", "ruby/installed":
 		return "Ruby"
 	case "rust":
 		return "Rust"
 	case "swift":
 		return "Swift"
+	case "cocoapods":
+		return "CocoaPods"
+	case "carthage":
+		return "Carthage"
+	case "nuget":
+		return "NuGet"
+	case "dart":
+		return "Dart"
+	case "hex":
+		return "Hex"
+	case "conan":
+		return "Conan"
+	case "vcpkg":
+		return "vcpkg"
+	case "cmake":
+		return "CMake"
+	case "sbt":
+		return "sbt"
+	case "clojure":
+		return "Clojure"
+	case "bazel":
+		return "Bazel"
+	case "cran":
+		return "CRAN"
+	case "julia":
+		return "Julia"
+	case "hackage":
+		return "Hackage"
+	case "conda":
+		return "Conda"
+	case "deno":
+		return "Deno"
+	case "docker":
+		return "Docker"
+	case "github-actions":
+		return "GitHubActions"
+	case "terraform":
+		return "Terraform"
+	case "helm":
+		return "Helm"
+	case "git-submodule":
+		return "GitSubmodule"
+	case "java-archive":
+		return "Maven"
+	case "dpkg":
+		return "Debian"
+	case "apk":
+		return "Alpine"
+	case "rpm":
+		return "RPM"
 	default:
 		return key
 	}
@@ -65,11 +154,12 @@ func niceName(key string) string {
 * Purpose: Extract module dependency names and versions from a go.mod file.
 *          This is a conservative line-based parser that handles 'require' blocks,
 *          single-line requires, comments (//), and simple replace directives.
+*          Requirements marked // indirect are noted indirect.
 * Parameters: path string
-* Output: []string (format: module@version or module => replacement)
+* Output: []string (format: module@version [(indirect)] or module => replacement)
 *************************************/
 func parseGoModDeps(path string) []string {
-	b, err := os.ReadFile(path)
+	b, err := readSourceFile(path)
 	if err != nil {
 		return nil
 	}
@@ -83,8 +173,12 @@ func parseGoModDeps(path string) []string {
 		if ln == "" {
 			continue
 		}
-		// strip inline comments
+		// strip inline comments, keeping the indirect marker
+		indirect := ""
 		if idx := strings.Index(ln, "//"); idx != -1 {
+			if strings.HasPrefix(strings.TrimSpace(ln[idx+2:]), "indirect") {
+				indirect = "indirect"
+			}
 			ln = strings.TrimSpace(ln[:idx])
 			if ln == "" {
 				continue
@@ -107,7 +201,7 @@ func parseGoModDeps(path string) []string {
 			if len(parts) >= 2 {
 				name := parts[0]
 				ver := parts[1]
-				deps[fmt.Sprintf("%s@%s", name, ver)] = struct{}{}
+				deps[annotateDep(fmt.Sprintf("%s@%s", name, ver), indirect)] = struct{}{}
 			}
 			continue
 		}
@@ -120,7 +214,7 @@ func parseGoModDeps(path string) []string {
 			if len(parts) >= 2 {
 				name := parts[0]
 				ver := parts[1]
-				deps[fmt.Sprintf("%s@%s", name, ver)] = struct{}{}
+				deps[annotateDep(fmt.Sprintf("%s@%s", name, ver), indirect)] = struct{}{}
 			}
 			continue
 		}
@@ -154,11 +248,16 @@ func parseGoModDeps(path string) []string {
 
 /************************************
 * Function Name: parsePackageJSONDeps
-* Purpose: Extract dependency names and versions from a package.json (dependencies + devDependencies).
-* Parameters: path string
-* Output: []string (format: name@version)
+* Purpose: Extract dependency names and versions from a package.json. Every
+*          dependency field is read and tagged (dev, peer, optional, bundled);
+*          plain "dependencies" are untagged. Within a workspace, links to
+*          other members are marked internal, pnpm catalog: versions are
+*          resolved and root overrides / resolutions replace the declared
+*          version. A workspace root also lists its members.
+* Parameters: path string, root string (scan root; workspace search stops here)
+* Output: []string (format: name@version (dev|peer|optional|bundled, internal, override))
 *************************************/
-func parsePackageJSONDeps(path string) []string {
+func parsePackageJSONDeps(path, root string) []string {
 	s, err := readFileContent(path)
 	if err != nil {
 		return nil
@@ -167,8 +266,37 @@ func parsePackageJSONDeps(path string) []string {
 	if err := json.Unmarshal([]byte(s), &data); err != nil {
 		return nil
 	}
+	ws := loadNodeWorkspace(filepath.Dir(path), root)
+
+	bundled := map[string]bool{}
+	for _, field := range []string{"bundledDependencies", "bundleDependencies"} {
+		switch v := data[field].(type) {
+		case []interface{}:
+			for _, name := range v {
+				bundled[fmt.Sprintf("%v", name)] = true
+			}
+		case bool:
+			// true bundles every runtime dependency
+			if deps, ok := data["dependencies"].(map[string]interface{}); ok && v {
+				for name := range deps {
+					bundled[name] = true
+				}
+			}
+		}
+	}
+
 	set := map[string]struct{}{}
-	if deps, ok := data["dependencies"].(map[string]interface{}); ok {
+	fields := []struct{ field, note string }{
+		{"dependencies", ""},
+		{"devDependencies", "dev"},
+		{"peerDependencies", "peer"},
+		{"optionalDependencies", "optional"},
+	}
+	for _, f := range fields {
+		deps, ok := data[f.field].(map[string]interface{})
+		if !ok {
+			continue
+		}
 		for k, v := range deps {
 			ver := ""
 			switch vv := v.(type) {
@@ -177,19 +305,56 @@ func parsePackageJSONDeps(path string) []string {
 			default:
 				ver = fmt.Sprintf("%v", vv)
 			}
-			set[fmt.Sprintf("%s@%s", k, ver)] = struct{}{}
+			notes := []string{f.note}
+			if f.field == "dependencies" && bundled[k] {
+				notes = append(notes, "bundled")
+				delete(bundled, k)
+			}
+			name := k
+			switch {
+			case strings.HasPrefix(ver, "workspace:"):
+				ver = strings.TrimLeft(strings.TrimPrefix(ver, "workspace:"), "*^~")
+				notes = append(notes, "internal")
+			case strings.HasPrefix(ver, "file:"), strings.HasPrefix(ver, "link:"):
+				notes = append(notes, "path: "+ver[strings.Index(ver, ":")+1:])
+				ver = ""
+			case strings.HasPrefix(ver, "npm:"):
+				// "alias": "npm:real-name@1.0.0"
+				name, ver = splitRegistrySpecifier(strings.TrimPrefix(ver, "npm:"))
+				notes = append(notes, "alias: "+k)
+			case strings.HasPrefix(ver, "catalog:") && ws != nil:
+				catalog := strings.TrimPrefix(ver, "catalog:")
+				if catalog == "" {
+					catalog = "default"
+				}
+				ver = ws.catalogs[catalog][k]
+				notes = append(notes, "catalog: "+catalog)
+			}
+			if ws != nil {
+				if dir, ok := ws.members[name]; ok {
+					if len(notes) == 0 || notes[len(notes)-1] != "internal" {
+						notes = append(notes, "internal")
+					}
+					notes = append(notes, "path: "+dir)
+				} else if o, ok := ws.overrides[name]; ok && o != ver {
+					ver = o
+					notes = append(notes, "override")
+				}
+			}
+			dep := name
+			if ver != "" {
+				dep = fmt.Sprintf("%s@%s", name, ver)
+			}
+			set[annotateDep(dep, notes...)] = struct{}{}
 		}
 	}
-	if dev, ok := data["devDependencies"].(map[string]interface{}); ok {
-		for k, v := range dev {
-			ver := ""
-			switch vv := v.(type) {
-			case string:
-				ver = vv
-			default:
-				ver = fmt.Sprintf("%v", vv)
-			}
-			set[fmt.Sprintf("%s@%s", k, ver)] = struct{}{}
+	for name := range bundled {
+		set[annotateDep(name, "bundled")] = struct{}{}
+	}
+	// the workspace root lists its members
+	if ws != nil && filepath.Clean(ws.dir) == filepath.Dir(path) {
+		for name, dir := range ws.members {
+			set[annotateDep(name, "internal", "path: "+dir)] = struct{}{}
 		}
 	}
 	return setToSortedSlice(set)
@@ -296,7 +461,7 @@ func aggregatePomData(repoRoot string) (map[string]string, map[string]string) {
 	allProps := map[string]string{}
 	allDM := map[string]string{}
 	// walk repository for pom.xml
-	filepath.Walk(repoRoot, func(path string, info os.FileInfo, err error) error {
+	walkSource(repoRoot, func(path string, info os.FileInfo, err error) error {
 		if err != nil || info.IsDir() {
 			return nil
 		}
@@ -507,8 +672,18 @@ func parseRequirementsTxtDeps(path string) []string {
 	if err != nil {
 		return nil
 	}
+	return parseRequirementLines(strings.Split(s, "\n"))
+}
+
+/************************************
+* Function Name: parseRequirementLines
+* Purpose: Extract name==version pins from pip requirement lines. Shared by
+*          requirements.txt and other manifests that embed pip requirements.
+* Parameters: lines []string
+* Output: []string (format: name@version)
+*************************************/
+func parseRequirementLines(lines []string) []string {
 	deps := []string{}
-	lines := strings.Split(s, "\n")
 
 	for _, line := range lines {
 		line = strings.TrimSpace(line)
@@ -519,7 +694,7 @@ func parseRequirementsTxtDeps(path string) []string {
 		// Extract dependency name and version by splitting on '=='
 		if strings.Contains(line, "==") {
 			parts := strings.SplitN(line, "==", 2)
-			if len(parts) == 2 {
+			if len(parts) == 2 && len(strings.Fields(parts[1])) > 0 {
 				name := strings.TrimSpace(parts[0])
 				version := strings.Fields(parts[1])[0] // Ensure no extra characters
 				deps = append(deps, fmt.Sprintf("%s@%s", name, version))
@@ -568,28 +743,283 @@ func parseSetupPyDeps(path string) []string {
 	return deps
 }
 
+/************************************
+* Function Name: stripLineComments
+* Purpose: Remove comments that start with marker and run to the end of the line,
+*          ignoring markers inside double-quoted strings.
+* Parameters: s string, marker string
+* Output: string
+*************************************/
+func stripLineComments(s, marker string) string {
+	return stripQuotedLineComments(s, marker, `"`)
+}
+
+/************************************
+* Function Name: stripPythonComments
+* Purpose: Remove # comments from Python or Starlark source, where strings may
+*          use single or double quotes (e.g. 'zlib/1.3#rev' is kept).
+* Parameters: s string
+* Output: string
+*************************************/
+func stripPythonComments(s string) string {
+	return stripQuotedLineComments(s, "#", `"'`)
+}
+
+/************************************
+* Function Name: stripQuotedLineComments
+* Purpose: Remove comments that start with marker and run to the end of the line,
+*          ignoring markers inside strings delimited by any of the quote characters.
+* Parameters: s string, marker string, quotes string (e.g. `"'`)
+* Output: string
+*************************************/
+func stripQuotedLineComments(s, marker, quotes string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		var quote byte // the quote that opened the current string, 0 outside strings
+		for j := 0; j < len(line); j++ {
+			c := line[j]
+			if strings.IndexByte(quotes, c) != -1 && (j == 0 || line[j-1] != '\\') {
+				switch quote {
+				case 0:
+					quote = c
+				case c:
+					quote = 0
+				}
+			}
+			if quote == 0 && strings.HasPrefix(line[j:], marker) {
+				lines[i] = line[:j]
+				break
+			}
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+/************************************
+* Function Name: stripCComments
+* Purpose: Remove // line comments and block comments from C-like source
+*          (Package.swift, ...), ignoring markers inside double-quoted strings.
+*          Line breaks are kept so line-based matching still works.
+* Parameters: s string
+* Output: string
+*************************************/
+func stripCComments(s string) string {
+	var b strings.Builder
+	inString := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case inString:
+			b.WriteByte(c)
+			if c == '\\' && i+1 < len(s) {
+				i++
+				b.WriteByte(s[i])
+			} else if c == '"' || c == '\n' {
+				inString = false
+			}
+		case c == '"':
+			inString = true
+			b.WriteByte(c)
+		case c == '/' && i+1 < len(s) && s[i+1] == '/':
+			for i+1 < len(s) && s[i+1] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < len(s) && s[i+1] == '*':
+			end := strings.Index(s[i+2:], "*/")
+			if end == -1 {
+				end = len(s) - i - 2
+			}
+			b.WriteString(strings.Repeat("\n", strings.Count(s[i:i+2+end], "\n")))
+			i += end + 3
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
 /************************************
 * Function Name: parsePackageSwiftDeps
 * Purpose: Extract dependencies from the dependencies array in Package.swift files.
+*          Handles url-based requirements (from:, .upToNextMajor/Minor, exact:,
+*          ranges, branch:, revision:) as well as the legacy name: form.
 * Parameters: path string
-* Output: []string (format: name@version)
+* Output: []string (format: identity@version, or identity (branch: x) / (revision: x))
 *************************************/
 func parsePackageSwiftDeps(path string) []string {
 	s, err := readFileContent(path)
 	if err != nil {
 		return nil
 	}
-	deps := []string{}
+	// commented-out packages are not dependencies
+	s = stripCComments(s)
+	deps := map[string]struct{}{}
 
-	// Match dependencies in the dependencies array
-	re := regexp.MustCompile(`\.package\(name:\s*\"([^"]+)\".*?\)`) // Match .package(name: "...")
-	for _, match := range re.FindAllStringSubmatch(s, -1) {
-		if len(match) > 1 {
-			deps = append(deps, match[1])
+	reURL := regexp.MustCompile(`url:\s*"([^"]+)"`)
+	reName := regexp.MustCompile(`name:\s*"([^"]+)"`)
+	reFrom := regexp.MustCompile(`from:\s*"([^"]+)"`)
+	reExact := regexp.MustCompile(`(?:exact:\s*|\.exact\(\s*)"([^"]+)"`)
+	reRange := regexp.MustCompile(`"([^"]+)"\s*\.\.[.<]\s*"[^"]+"`)
+	reBranch := regexp.MustCompile(`(?:branch:\s*|\.branch\(\s*)"([^"]+)"`)
+	reRevision := regexp.MustCompile(`(?:revision:\s*|\.revision\(\s*)"([^"]+)"`)
+
+	for _, call := range findCallArgs(s, ".package(") {
+		id := ""
+		if m := reURL.FindStringSubmatch(call); len(m) > 1 {
+			id = swiftPackageIdentity(m[1])
+		} else if m := reName.FindStringSubmatch(call); len(m) > 1 {
+			id = m[1]
+		}
+		if id == "" {
+			continue // local path packages
+		}
+		switch {
+		case reExact.MatchString(call):
+			deps[fmt.Sprintf("%s@%s", id, reExact.FindStringSubmatch(call)[1])] = struct{}{}
+		case reFrom.MatchString(call):
+			deps[fmt.Sprintf("%s@%s", id, reFrom.FindStringSubmatch(call)[1])] = struct{}{}
+		case reRange.MatchString(call):
+			deps[fmt.Sprintf("%s@%s", id, reRange.FindStringSubmatch(call)[1])] = struct{}{}
+		case reBranch.MatchString(call):
+			deps[annotateDep(id, "branch: "+reBranch.FindStringSubmatch(call)[1])] = struct{}{}
+		case reRevision.MatchString(call):
+			deps[annotateDep(id, "revision: "+reRevision.FindStringSubmatch(call)[1])] = struct{}{}
+		default:
+			deps[id] = struct{}{}
 		}
 	}
 
-	return deps
+	return setToSortedSlice(deps)
+}
+
+/************************************
+* Function Name: findCallArgs
+* Purpose: Return the argument text of every call starting with prefix (which must
+*          end in '('), honouring nested parentheses and skipping quoted strings.
+* Parameters: s string, prefix string
+* Output: []string
+*************************************/
+func findCallArgs(s, prefix string) []string {
+	var calls []string
+	for offset := 0; ; {
+		idx := strings.Index(s[offset:], prefix)
+		if idx == -1 {
+			break
+		}
+		start := offset + idx + len(prefix)
+		depth := 1
+		inString := false
+		end := -1
+		for i := start; i < len(s) && end == -1; i++ {
+			switch c := s[i]; {
+			case c == '\\' && inString:
+				i++
+			case c == '"':
+				inString = !inString
+			case inString:
+			case c == '(':
+				depth++
+			case c == ')':
+				depth--
+				if depth == 0 {
+					end = i
+				}
+			}
+		}
+		if end == -1 {
+			break
+		}
+		calls = append(calls, s[start:end])
+		offset = end + 1
+	}
+	return calls
+}
+
+/************************************
+* Function Name: swiftPackageIdentity
+* Purpose: Derive a SwiftPM package identity from a repository URL: the last
+*          path component, lowercased, without a trailing .git.
+* Parameters: repoURL string
+* Output: string
+*************************************/
+func swiftPackageIdentity(repoURL string) string {
+	u := strings.TrimRight(strings.TrimSpace(repoURL), "/")
+	if idx := strings.LastIndexAny(u, "/:"); idx != -1 {
+		u = u[idx+1:]
+	}
+	return strings.ToLower(strings.TrimSuffix(u, ".git"))
+}
+
+/************************************
+* Function Name: parsePackageResolvedDeps
+* Purpose: Extract pinned packages from a SwiftPM Package.resolved file.
+*          Supports format v1 (object.pins with repositoryURL) and v2/v3 (pins with location).
+* Parameters: path string
+* Output: []string (format: identity@version (revision: x))
+*************************************/
+func parsePackageResolvedDeps(path string) []string {
+	s, err := readFileContent(path)
+	if err != nil {
+		return nil
+	}
+	type pin struct {
+		Package       string `json:"package"`
+		RepositoryURL string `json:"repositoryURL"`
+		Identity      string `json:"identity"`
+		Location      string `json:"location"`
+		State         struct {
+			Branch   string `json:"branch"`
+			Revision string `json:"revision"`
+			Version  string `json:"version"`
+		} `json:"state"`
+	}
+	var data struct {
+		Version int   `json:"version"`
+		Pins    []pin `json:"pins"`
+		Object  struct {
+			Pins []pin `json:"pins"`
+		} `json:"object"`
+	}
+	if err := json.Unmarshal([]byte(s), &data); err != nil {
+		return nil
+	}
+	pins := data.Pins
+	if data.Version == 1 {
+		pins = data.Object.Pins
+	}
+
+	deps := map[string]struct{}{}
+	for _, p := range pins {
+		id := p.Identity
+		if id == "" {
+			loc := p.Location
+			if loc == "" {
+				loc = p.RepositoryURL
+			}
+			id = swiftPackageIdentity(loc)
+		}
+		if id == "" {
+			id = strings.ToLower(p.Package)
+		}
+		if id == "" {
+			continue
+		}
+		branch := ""
+		if p.State.Branch != "" {
+			branch = "branch: " + p.State.Branch
+		}
+		revision := ""
+		if p.State.Revision != "" {
+			revision = "revision: " + p.State.Revision
+		}
+		if p.State.Version != "" {
+			deps[annotateDep(fmt.Sprintf("%s@%s", id, p.State.Version), branch, revision)] = struct{}{}
+		} else {
+			deps[annotateDep(id, branch, revision)] = struct{}{}
+		}
+	}
+
+	return setToSortedSlice(deps)
 }
 
 /************************************
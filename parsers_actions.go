@@ -0,0 +1,96 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+/************************************
+* Function Name: actionDep
+* Purpose: Turn a workflow "uses:" value into a dependency entry. Repository
+*          actions and reusable workflows (owner/repo[/path]@ref) are noted as
+*          pinned when the ref is a full commit SHA and mutable otherwise;
+*          docker:// references become images and ./local actions internal.
+* Parameters: uses string
+* Output: string (format: owner/repo@ref (pinned|mutable) or image entry)
+*************************************/
+func actionDep(uses string) string {
+	uses = strings.TrimSpace(uses)
+	switch {
+	case uses == "":
+		return ""
+	case strings.HasPrefix(uses, "docker://"):
+		ref := strings.TrimPrefix(uses, "docker://")
+		pin := "mutable"
+		if strings.Contains(ref, "@sha256:") {
+			pin = "pinned"
+		}
+		return imageDep(ref, pin)
+	case strings.HasPrefix(uses, "./") || strings.HasPrefix(uses, "../"):
+		return annotateDep(uses, "internal", "path: "+uses)
+	}
+	idx := strings.LastIndex(uses, "@")
+	if idx == -1 {
+		return annotateDep(uses, "mutable")
+	}
+	pin := "mutable"
+	if regexp.MustCompile(`^[0-9a-f]{40}$`).MatchString(uses[idx+1:]) {
+		pin = "pinned"
+	}
+	return annotateDep(uses, pin)
+}
+
+/************************************
+* Function Name: parseWorkflowDeps
+* Purpose: Extract action references from a GitHub Actions workflow
+*          (jobs.*.steps[].uses, reusable workflow jobs.*.uses and job
+*          container / service images) or from an action.yml (composite
+*          runs.steps[].uses and docker runs.image).
+* Parameters: path string
+* Output: []string (format: owner/repo@ref (pinned|mutable))
+*************************************/
+func parseWorkflowDeps(path string) []string {
+	s, err := readFileContent(path)
+	if err != nil {
+		return nil
+	}
+	doc := yamlMap(parseYAML(s))
+	deps := map[string]struct{}{}
+	add := func(dep string) {
+		if dep != "" {
+			deps[dep] = struct{}{}
+		}
+	}
+	addSteps := func(steps interface{}) {
+		for _, step := range yamlList(steps) {
+			add(actionDep(yamlString(yamlMap(step)["uses"])))
+		}
+	}
+	// container: may be a plain image string or a map with an image key
+	addImage := func(v interface{}) {
+		ref := yamlString(v)
+		if ref == "" {
+			ref = yamlString(yamlMap(v)["image"])
+		}
+		if ref != "" && !strings.Contains(ref, "${{") {
+			add(actionDep("docker://" + ref))
+		}
+	}
+
+	for _, job := range yamlMap(doc["jobs"]) {
+		j := yamlMap(job)
+		add(actionDep(yamlString(j["uses"])))
+		addSteps(j["steps"])
+		addImage(j["container"])
+		for _, svc := range yamlMap(j["services"]) {
+			addImage(svc)
+		}
+	}
+	runs := yamlMap(doc["runs"])
+	addSteps(runs["steps"])
+	if image := yamlString(runs["image"]); strings.HasPrefix(image, "docker://") {
+		add(actionDep(image))
+	}
+
+	return setToSortedSlice(deps)
+}
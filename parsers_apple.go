@@ -0,0 +1,263 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+/************************************
+* Function Name: podRequirement
+* Purpose: Normalize a CocoaPods / Carthage version requirement: an exact
+*          "= 1.0" or "== 1.0" becomes "1.0", other operators are kept without
+*          the space ("~> 5.0" becomes "~>5.0") so they do not read as pins.
+* Parameters: req string
+* Output: string
+*************************************/
+func podRequirement(req string) string {
+	req = strings.TrimSpace(req)
+	op := strings.TrimRight(req[:len(req)-len(strings.TrimLeft(req, "~<>=!"))], " ")
+	v := strings.TrimSpace(strings.TrimLeft(req, "~<>=!"))
+	if op == "=" || op == "==" {
+		return v
+	}
+	return op + v
+}
+
+/************************************
+* Function Name: parsePodfileDeps
+* Purpose: Extract pods declared in a CocoaPods Podfile, tagging each with the
+*          target it belongs to. Subspecs keep their full Pod/Subspec name.
+* Parameters: path string
+* Output: []string (format: name@requirement (target: x))
+*************************************/
+func parsePodfileDeps(path string) []string {
+	s, err := readFileContent(path)
+	if err != nil {
+		return nil
+	}
+	deps := map[string]struct{}{}
+
+	reTarget := regexp.MustCompile(`^(?:abstract_)?target\s+:?['"]?([^'"\s]+)['"]?\s+do\b`)
+	// Ruby block openers; each is closed by an end that must not pop a target
+	reBlock := regexp.MustCompile(`\bdo(?:\s*\|[^|]*\|)?\s*$|^(?:if|unless|def|case|begin|while|until|for|class|module)\b`)
+	rePod := regexp.MustCompile(`^pod\s+['"]([^'"]+)['"](.*)$`)
+	reVersion := regexp.MustCompile(`^\s*,\s*['"]([^'"]+)['"]`)
+	reOption := regexp.MustCompile(`:(git|tag|branch|commit|path|podspec)\s*=>\s*['"]([^'"]+)['"]`)
+
+	// stack of open blocks closed by end; non-target blocks push ""
+	var targets []string
+	for _, raw := range strings.Split(s, "\n") {
+		line := strings.TrimSpace(raw)
+		if idx := strings.Index(line, "#"); idx != -1 {
+			line = strings.TrimSpace(line[:idx])
+		}
+		if line == "" {
+			continue
+		}
+		if m := reTarget.FindStringSubmatch(line); len(m) > 1 {
+			targets = append(targets, m[1])
+			continue
+		}
+		if line == "end" {
+			if len(targets) > 0 {
+				targets = targets[:len(targets)-1]
+			}
+			continue
+		}
+		if m := rePod.FindStringSubmatch(line); len(m) > 1 {
+			dep := m[1]
+			if vm := reVersion.FindStringSubmatch(m[2]); len(vm) > 1 {
+				dep = fmt.Sprintf("%s@%s", dep, podRequirement(vm[1]))
+			}
+			var notes []string
+			for i := len(targets) - 1; i >= 0; i-- {
+				if targets[i] != "" {
+					notes = append(notes, "target: "+targets[i])
+					break
+				}
+			}
+			for _, om := range reOption.FindAllStringSubmatch(m[2], -1) {
+				notes = append(notes, fmt.Sprintf("%s: %s", om[1], om[2]))
+			}
+			deps[annotateDep(dep, notes...)] = struct{}{}
+			continue
+		}
+		if reBlock.MatchString(line) {
+			targets = append(targets, "")
+		}
+	}
+
+	return setToSortedSlice(deps)
+}
+
+/************************************
+* Function Name: parsePodfileLockDeps
+* Purpose: Extract exact pod versions from the PODS section of a Podfile.lock,
+*          attaching the SPEC CHECKSUMS entry and flagging pods that are not
+*          listed under DEPENDENCIES as transitive.
+* Parameters: path string
+* Output: []string (format: name@version (spec-checksum: x[, transitive]))
+*************************************/
+func parsePodfileLockDeps(path string) []string {
+	s, err := readFileContent(path)
+	if err != nil {
+		return nil
+	}
+
+	rePod := regexp.MustCompile(`^  - "?([^\s"(]+)(?: \(([^)]+)\))?"?:?$`)
+	reChecksum := regexp.MustCompile(`^  "?([^\s":]+)"?:\s*([0-9a-fA-F]+)$`)
+
+	type pod struct{ name, version string }
+	var pods []pod
+	direct := map[string]bool{}
+	checksums := map[string]string{}
+
+	section := ""
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimRight(line, " \r")
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, " ") {
+			section = strings.TrimSuffix(strings.SplitN(line, ":", 2)[0], ":")
+			continue
+		}
+		switch section {
+		case "PODS":
+			if m := rePod.FindStringSubmatch(line); len(m) > 1 {
+				pods = append(pods, pod{m[1], m[2]})
+			}
+		case "DEPENDENCIES":
+			if m := rePod.FindStringSubmatch(line); len(m) > 1 {
+				direct[strings.SplitN(m[1], "/", 2)[0]] = true
+			}
+		case "SPEC CHECKSUMS":
+			if m := reChecksum.FindStringSubmatch(line); len(m) > 2 {
+				checksums[m[1]] = m[2]
+			}
+		}
+	}
+
+	deps := map[string]struct{}{}
+	for _, p := range pods {
+		root := strings.SplitN(p.name, "/", 2)[0]
+		dep := p.name
+		if p.version != "" {
+			dep = fmt.Sprintf("%s@%s", p.name, p.version)
+		}
+		checksum := ""
+		if c, ok := checksums[root]; ok {
+			checksum = "spec-checksum: " + c
+		}
+		transitive := ""
+		if len(direct) > 0 && !direct[root] {
+			transitive = "transitive"
+		}
+		deps[annotateDep(dep, checksum, transitive)] = struct{}{}
+	}
+
+	return setToSortedSlice(deps)
+}
+
+/************************************
+* Function Name: podfileLockEdges
+* Purpose: Read the dependency graph of a Podfile.lock: every pod under PODS
+*          lists the pods it requires, which are resolved to their locked
+*          versions.
+* Parameters: path string
+* Output: map[string][]string (name@version -> name@version of its dependencies)
+*************************************/
+func podfileLockEdges(path string) map[string][]string {
+	s, err := readFileContent(path)
+	if err != nil {
+		return nil
+	}
+
+	rePod := regexp.MustCompile(`^  - "?([^\s"(]+)(?: \(([^)]+)\))?"?:?$`)
+	reChild := regexp.MustCompile(`^    - "?([^\s"(]+)`)
+
+	locked := map[string]string{} // name -> name@version
+	children := map[string][]string{}
+	parent := ""
+	inPods := false
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimRight(line, " \r")
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, " ") {
+			inPods = strings.HasPrefix(line, "PODS:")
+			continue
+		}
+		if !inPods {
+			continue
+		}
+		if m := rePod.FindStringSubmatch(line); len(m) > 1 {
+			parent = m[1]
+			locked[parent] = parent
+			if m[2] != "" {
+				locked[parent] = fmt.Sprintf("%s@%s", m[1], m[2])
+			}
+		} else if m := reChild.FindStringSubmatch(line); len(m) > 1 && parent != "" {
+			children[parent] = append(children[parent], m[1])
+		}
+	}
+
+	edges := map[string][]string{}
+	for name, deps := range children {
+		for _, child := range deps {
+			if dep, ok := locked[child]; ok {
+				edges[locked[name]] = append(edges[locked[name]], dep)
+			}
+		}
+	}
+	return edges
+}
+
+/************************************
+* Function Name: parseCartfileDeps
+* Purpose: Extract dependencies from a Cartfile, Cartfile.private or Cartfile.resolved.
+*          Supports github, git and binary origins; resolved files carry exact tags
+*          or commits, while Cartfiles may carry a version requirement or a branch.
+* Parameters: path string
+* Output: []string (format: source@requirement (origin: github|git|binary))
+*************************************/
+func parseCartfileDeps(path string) []string {
+	s, err := readFileContent(path)
+	if err != nil {
+		return nil
+	}
+	deps := map[string]struct{}{}
+
+	reLine := regexp.MustCompile(`^(github|git|binary)\s+"([^"]+)"\s*(.*)$`)
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		if idx := strings.Index(line, "#"); idx != -1 {
+			line = strings.TrimSpace(line[:idx])
+		}
+		m := reLine.FindStringSubmatch(line)
+		if len(m) < 3 {
+			continue
+		}
+		origin, source, req := m[1], m[2], strings.TrimSpace(m[3])
+		dep := source
+		ref := ""
+		switch {
+		case req == "":
+		case strings.HasPrefix(req, `"`):
+			// quoted value: exact tag/commit in resolved files, branch or tag in Cartfiles
+			v := strings.Trim(req, `"`)
+			if strings.HasSuffix(strings.ToLower(path), ".resolved") {
+				dep = fmt.Sprintf("%s@%s", source, v)
+			} else {
+				ref = "ref: " + v
+			}
+		default:
+			dep = fmt.Sprintf("%s@%s", source, podRequirement(req))
+		}
+		deps[annotateDep(dep, "origin: "+origin, ref)] = struct{}{}
+	}
+
+	return setToSortedSlice(deps)
+}
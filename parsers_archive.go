@@ -0,0 +1,354 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// javaArchiveMaxDepth bounds recursion into jars nested inside jars.
+const javaArchiveMaxDepth = 4
+
+// reSHA1Hex matches the checksum column of a -sha1-index line.
+var reSHA1Hex = regexp.MustCompile(`^[0-9a-fA-F]{40}$`)
+
+// jarSHA1Index maps a jar's SHA-1 to group:artifact:version; loaded with -sha1-index.
+var jarSHA1Index map[string]string
+
+/************************************
+* Function Name: loadSHA1Index
+* Purpose: Load a local SHA-1 index of known jars. Each line holds a hex SHA-1
+*          and a groupId:artifactId:version coordinate separated by whitespace
+*          or a comma; blank lines, # comments and lines without a valid
+*          SHA-1 and group:artifact coordinate are ignored.
+* Parameters: path string
+* Output: map[string]string, error
+*************************************/
+func loadSHA1Index(path string) (map[string]string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	index := map[string]string{}
+	for _, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(strings.Replace(line, ",", " ", 1))
+		if len(fields) < 2 || !reSHA1Hex.MatchString(fields[0]) || mavenCoordinate(fields[1]) == "" {
+			continue
+		}
+		index[strings.ToLower(fields[0])] = fields[1]
+	}
+	return index, nil
+}
+
+/************************************
+* Function Name: isJavaArchive
+* Purpose: Report whether a file name is a Java archive (jar, war, ear, ...).
+* Parameters: name string
+* Output: bool
+*************************************/
+func isJavaArchive(name string) bool {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".jar", ".war", ".ear", ".rar", ".hpi", ".jpi", ".par", ".sar":
+		return true
+	}
+	return false
+}
+
+/************************************
+* Function Name: parseManifest
+* Purpose: Parse a META-INF/MANIFEST.MF main section. Continuation lines start
+*          with a single space.
+* Parameters: s string
+* Output: map[string]string
+*************************************/
+func parseManifest(s string) map[string]string {
+	attrs := map[string]string{}
+	last := ""
+	for _, line := range strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n") {
+		if line == "" {
+			break // end of the main section
+		}
+		if strings.HasPrefix(line, " ") && last != "" {
+			attrs[last] += line[1:]
+			continue
+		}
+		if kv := strings.SplitN(line, ":", 2); len(kv) == 2 {
+			last = strings.TrimSpace(kv[0])
+			attrs[last] = strings.TrimSpace(kv[1])
+		}
+	}
+	return attrs
+}
+
+/************************************
+* Function Name: archiveFilenameDep
+* Purpose: Split an archive file name such as commons-lang3-3.12.0.jar into
+*          artifact and version.
+* Parameters: name string
+* Output: artifact string, version string
+*************************************/
+func archiveFilenameDep(name string) (string, string) {
+	base := strings.TrimSuffix(path.Base(name), path.Ext(name))
+	reName := regexp.MustCompile(`^(.+?)-(\d[\w.\-]*)$`)
+	if m := reName.FindStringSubmatch(base); m != nil {
+		return m[1], m[2]
+	}
+	return base, ""
+}
+
+// relocationMarkers are package segments that shading tools commonly use when
+// relocating bundled libraries (com/acme/shaded/com/google/common/...).
+// vendor and thirdparty are left out: projects use them for their own code.
+var relocationMarkers = map[string]bool{"shaded": true, "shade": true, "relocated": true, "repackaged": true}
+
+/************************************
+* Function Name: scanJavaArchive
+* Purpose: Inspect one (possibly nested) Java archive. Coordinates come from
+*          META-INF/maven/<group>/<artifact>/pom.properties; an archive without them is
+*          identified by the SHA-1 index, then MANIFEST.MF, then its file name.
+*          Nested jars are scanned recursively, extra pom.properties of the
+*          outermost archive are reported as shaded and relocated packages as
+*          shaded embedded code.
+* Parameters: data []byte, name string (file name), location string (nesting
+*          path, empty for the scanned file), depth int, budget *int64 (bytes
+*          left for nested entries), deps map[string]struct{}
+* Output: none (adds entries to deps)
+*************************************/
+func scanJavaArchive(data []byte, name, location string, depth int, budget *int64, deps map[string]struct{}) {
+	sum := sha1.Sum(data)
+	sha := hex.EncodeToString(sum[:])
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return
+	}
+	where := ""
+	if location != "" {
+		where = "embedded: " + location
+	}
+
+	type pomProps struct{ group, artifact, version string }
+	var poms []pomProps
+	var manifest map[string]string
+	relocated := map[string]string{}
+	for _, f := range zr.File {
+		switch {
+		case strings.HasPrefix(f.Name, "META-INF/maven/") && strings.HasSuffix(f.Name, "/pom.properties"):
+			props := map[string]string{}
+			for _, line := range strings.Split(readZipFile(f, budget), "\n") {
+				if kv := strings.SplitN(strings.TrimSpace(line), "=", 2); len(kv) == 2 && !strings.HasPrefix(kv[0], "#") {
+					props[kv[0]] = kv[1]
+				}
+			}
+			if props["groupId"] != "" && props["artifactId"] != "" {
+				poms = append(poms, pomProps{props["groupId"], props["artifactId"], props["version"]})
+			}
+		case f.Name == "META-INF/MANIFEST.MF":
+			manifest = parseManifest(readZipFile(f, budget))
+		case isJavaArchive(f.Name) && depth < javaArchiveMaxDepth:
+			if nested := readZipBytes(f, budget); nested != nil {
+				inner := f.Name
+				if location != "" {
+					inner = location + "!/" + f.Name
+				}
+				scanJavaArchive(nested, f.Name, inner, depth+1, budget, deps)
+			}
+		case strings.HasSuffix(f.Name, ".class"):
+			// com/acme/shaded/com/google/common/base/X.class -> com.google.common
+			segs := strings.Split(path.Dir(f.Name), "/")
+			for i, seg := range segs {
+				if !relocationMarkers[seg] || i == 0 || len(segs) < i+3 {
+					continue
+				}
+				end := i + 4
+				if end > len(segs) {
+					end = len(segs)
+				}
+				relocated[strings.Join(segs[i+1:end], ".")] = strings.Join(segs[:i+1], ".")
+				break
+			}
+		}
+	}
+
+	// the archive's own coordinates: the pom.properties matching its file name,
+	// or the only one present
+	fileArtifact, _ := archiveFilenameDep(name)
+	own := -1
+	for i, p := range poms {
+		if p.artifact == fileArtifact {
+			own = i
+		}
+	}
+	if own == -1 && len(poms) == 1 {
+		own = 0
+	}
+	for i, p := range poms {
+		dep := fmt.Sprintf("%s:%s", p.group, p.artifact)
+		if p.version != "" {
+			dep += "@" + p.version
+		}
+		if i == own {
+			deps[annotateDep(dep, "evidence: pom.properties", where, "sha1: "+sha)] = struct{}{}
+		} else {
+			// classes of another artifact merged into this archive
+			deps[annotateDep(dep, "evidence: pom.properties", "shaded", where)] = struct{}{}
+		}
+	}
+	for pkg, prefix := range relocated {
+		deps[annotateDep(pkg, "shaded", "relocated: "+prefix, where)] = struct{}{}
+	}
+	if own != -1 {
+		return
+	}
+
+	indexed := mavenCoordinate(jarSHA1Index[sha])
+	switch {
+	case indexed != "":
+		deps[annotateDep(indexed, "evidence: sha1", where, "sha1: "+sha)] = struct{}{}
+	case manifest["Implementation-Title"] != "" || manifest["Bundle-SymbolicName"] != "":
+		title := manifest["Implementation-Title"]
+		version := manifest["Implementation-Version"]
+		if title == "" {
+			title = strings.SplitN(manifest["Bundle-SymbolicName"], ";", 2)[0]
+			version = manifest["Bundle-Version"]
+		}
+		if vendor := manifest["Implementation-Vendor-Id"]; vendor != "" {
+			title = vendor + ":" + title
+		}
+		dep := title
+		if version != "" {
+			dep = fmt.Sprintf("%s@%s", title, version)
+		}
+		deps[annotateDep(dep, "evidence: manifest", where, "sha1: "+sha)] = struct{}{}
+	default:
+		artifact, version := archiveFilenameDep(name)
+		dep := artifact
+		if version != "" {
+			dep = fmt.Sprintf("%s@%s", artifact, version)
+		}
+		deps[annotateDep(dep, "evidence: filename", where, "sha1: "+sha)] = struct{}{}
+	}
+}
+
+/************************************
+* Function Name: readZipBytes
+* Purpose: Read the content of a zip entry. Entries above archiveMaxEntrySize
+*          or above the remaining budget are skipped, and the read never goes
+*          past the declared uncompressed size.
+* Parameters: f *zip.File, budget *int64 (decremented by the bytes read)
+* Output: []byte (nil on error or when the entry is skipped)
+*************************************/
+func readZipBytes(f *zip.File, budget *int64) []byte {
+	size := f.UncompressedSize64
+	if size > archiveMaxEntrySize || int64(size) > *budget {
+		return nil
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil
+	}
+	defer rc.Close()
+	b, err := io.ReadAll(io.LimitReader(rc, int64(size)))
+	if err != nil {
+		return nil
+	}
+	*budget -= int64(len(b))
+	return b
+}
+
+/************************************
+* Function Name: readZipFile
+* Purpose: Read the content of a zip entry as text.
+* Parameters: f *zip.File, budget *int64
+* Output: string
+*************************************/
+func readZipFile(f *zip.File, budget *int64) string {
+	return string(readZipBytes(f, budget))
+}
+
+/************************************
+* Function Name: parseJavaArchiveDeps
+* Purpose: Report the libraries contained in a JAR, WAR or EAR, including jars
+*          nested in BOOT-INF/lib, WEB-INF/lib or the EAR root.
+* Parameters: path string
+* Output: []string (format: group:artifact@version (evidence: x, embedded: path, shaded, sha1: x))
+*************************************/
+func parseJavaArchiveDeps(path string) []string {
+	data, err := readSourceFile(path)
+	if err != nil {
+		return nil
+	}
+	deps := map[string]struct{}{}
+	budget := int64(archiveMaxTotalSize)
+	scanJavaArchive(data, filepath.Base(path), "", 0, &budget, deps)
+	return setToSortedSlice(deps)
+}
+
+/************************************
+* Function Name: analyzeArchives
+* Purpose: Build an Analysis for a Java archive, or for every Java archive
+*          found under a directory.
+* Parameters: path string
+* Output: Analysis, error
+*************************************/
+func analyzeArchives(path string) (Analysis, error) {
+	var a Analysis
+	a.Repo = filepath.Base(path)
+	a.Dependencies = map[string]map[string][]string{}
+
+	st, err := fs.Stat(sourceFS, filepath.ToSlash(path))
+	if err != nil {
+		return a, err
+	}
+	root := filepath.Dir(path)
+	var files []string
+	if st.IsDir() {
+		root = path
+		walkSource(path, func(p string, info os.FileInfo, err error) error {
+			if err == nil && info.Mode().IsRegular() && isJavaArchive(p) {
+				files = append(files, p)
+			}
+			return nil
+		})
+	} else {
+		files = []string{path}
+	}
+
+	perFile := map[string][]string{}
+	for _, f := range files {
+		deps := parseJavaArchiveDeps(f)
+		if len(deps) == 0 {
+			if !st.IsDir() {
+				return a, fmt.Errorf("%s: not a readable Java archive", f)
+			}
+			continue
+		}
+		rel, err := filepath.Rel(root, f)
+		if err != nil {
+			rel = f
+		}
+		perFile[rel] = deps
+		a.Files = append(a.Files, rel)
+	}
+	if len(perFile) > 0 {
+		a.Type = []string{"Maven"}
+		a.Dependencies["Maven"] = perFile
+	}
+	sort.Strings(a.Files)
+	a.Purls = collectPurls(a.Dependencies)
+	a.fileSHA1 = fileChecksums(root, a.Files)
+	return a, nil
+}
@@ -0,0 +1,315 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+/************************************
+* Function Name: condaDep
+* Purpose: Turn a conda match spec ([channel::]name[=version[=build]], name==version,
+*          "name version build", name>=x, ...) into a dependency entry.
+* Parameters: spec string, notes ...string
+* Output: string (empty if spec has no package name)
+*************************************/
+func condaDep(spec string, notes ...string) string {
+	spec = strings.TrimSpace(spec)
+	var extra []string
+	if idx := strings.Index(spec, "::"); idx != -1 {
+		extra = append(extra, "channel: "+spec[:idx])
+		spec = spec[idx+2:]
+	}
+	reSpec := regexp.MustCompile(`^([A-Za-z0-9_.\-]+)\s*(.*)$`)
+	m := reSpec.FindStringSubmatch(spec)
+	if len(m) < 3 {
+		return ""
+	}
+	name, rest := m[1], strings.TrimSpace(m[2])
+	version, build := "", ""
+	switch {
+	case rest == "":
+	case strings.HasPrefix(rest, "=="):
+		version = strings.TrimSpace(rest[2:])
+	case strings.HasPrefix(rest, "="):
+		parts := strings.SplitN(rest[1:], "=", 2)
+		version = strings.TrimSpace(parts[0])
+		if len(parts) == 2 {
+			build = strings.TrimSpace(parts[1])
+		}
+	case strings.ContainsAny(rest[:1], "<>!~"):
+		version = strings.ReplaceAll(rest, " ", "")
+	default:
+		fields := strings.Fields(rest)
+		version = fields[0]
+		if len(fields) > 1 {
+			build = fields[1]
+		}
+	}
+	if build != "" {
+		extra = append(extra, "build: "+build)
+	}
+	dep := name
+	if version != "" {
+		dep = fmt.Sprintf("%s@%s", name, version)
+	}
+	return annotateDep(dep, append(extra, notes...)...)
+}
+
+/************************************
+* Function Name: condaChannelFromURL
+* Purpose: Derive the channel name from a conda package URL such as
+*          https://conda.anaconda.org/conda-forge/linux-64/numpy-1.26.0-....conda
+* Parameters: u string
+* Output: string
+*************************************/
+func condaChannelFromURL(u string) string {
+	u = strings.TrimPrefix(strings.TrimPrefix(u, "https://"), "http://")
+	parts := strings.Split(u, "/")
+	if len(parts) < 4 {
+		return ""
+	}
+	if parts[0] == "repo.anaconda.com" {
+		return "defaults"
+	}
+	// host/<channel...>/<subdir>/<file>
+	return strings.Join(parts[1:len(parts)-2], "/")
+}
+
+/************************************
+* Function Name: condaFilenameDep
+* Purpose: Split a conda package filename (name-version-build.conda or .tar.bz2)
+*          into name and version.
+* Parameters: u string (URL or filename)
+* Output: name string, version string
+*************************************/
+func condaFilenameDep(u string) (string, string) {
+	file := path.Base(u)
+	file = strings.TrimSuffix(strings.TrimSuffix(file, ".conda"), ".tar.bz2")
+	parts := strings.Split(file, "-")
+	if len(parts) < 3 {
+		return file, ""
+	}
+	return strings.Join(parts[:len(parts)-2], "-"), parts[len(parts)-2]
+}
+
+/************************************
+* Function Name: parseCondaEnvironmentDeps
+* Purpose: Extract packages from a conda environment.yml. Conda channel packages
+*          are parsed as match specs; the nested pip: list is run through the
+*          pip requirement parser and its entries are noted as pip.
+* Parameters: path string
+* Output: []string (format: name@version (channel: x) or name@version (pip))
+*************************************/
+func parseCondaEnvironmentDeps(path string) []string {
+	s, err := readFileContent(path)
+	if err != nil {
+		return nil
+	}
+	doc := yamlMap(parseYAML(s))
+	deps := map[string]struct{}{}
+
+	for _, item := range yamlList(doc["dependencies"]) {
+		switch v := item.(type) {
+		case string:
+			if dep := condaDep(v); dep != "" {
+				deps[dep] = struct{}{}
+			}
+		case map[string]interface{}:
+			var lines []string
+			for _, req := range yamlList(v["pip"]) {
+				lines = append(lines, yamlString(req))
+			}
+			for _, dep := range parseRequirementLines(lines) {
+				deps[annotateDep(dep, "pip")] = struct{}{}
+			}
+		}
+	}
+
+	return setToSortedSlice(deps)
+}
+
+/************************************
+* Function Name: parseCondaLockDeps
+* Purpose: Extract locked packages from a conda-lock.yml (format v1). Conda and
+*          pip managed packages are both reported; pip ones are noted as pip.
+* Parameters: path string
+* Output: []string (format: name@version (channel: x, platform: x, sha256: x))
+*************************************/
+func parseCondaLockDeps(path string) []string {
+	s, err := readFileContent(path)
+	if err != nil {
+		return nil
+	}
+	doc := yamlMap(parseYAML(s))
+	deps := map[string]struct{}{}
+
+	for _, item := range yamlList(doc["package"]) {
+		pkg := yamlMap(item)
+		name := yamlString(pkg["name"])
+		if name == "" {
+			continue
+		}
+		dep := name
+		if v := yamlString(pkg["version"]); v != "" {
+			dep = fmt.Sprintf("%s@%s", name, v)
+		}
+		var notes []string
+		if yamlString(pkg["manager"]) == "pip" {
+			notes = append(notes, "pip")
+		} else if ch := condaChannelFromURL(yamlString(pkg["url"])); ch != "" {
+			notes = append(notes, "channel: "+ch)
+		}
+		if p := yamlString(pkg["platform"]); p != "" {
+			notes = append(notes, "platform: "+p)
+		}
+		if c := yamlString(pkg["category"]); c != "" && c != "main" {
+			notes = append(notes, "category: "+c)
+		}
+		if sum := yamlString(yamlMap(pkg["hash"])["sha256"]); sum != "" {
+			notes = append(notes, "sha256: "+sum)
+		}
+		deps[annotateDep(dep, notes...)] = struct{}{}
+	}
+
+	return setToSortedSlice(deps)
+}
+
+/************************************
+* Function Name: parsePixiTomlDeps
+* Purpose: Extract conda and PyPI dependencies from a pixi.toml, including
+*          feature and target specific tables. PyPI entries are noted as pip.
+* Parameters: path string
+* Output: []string (format: name@constraint (pip, feature: x, platform: x))
+*************************************/
+func parsePixiTomlDeps(path string) []string {
+	s, err := readFileContent(path)
+	if err != nil {
+		return nil
+	}
+	deps := map[string]struct{}{}
+
+	reTable := regexp.MustCompile(`^(?:(feature|target)\.([^.]+)\.)?(dependencies|host-dependencies|build-dependencies|pypi-dependencies)$`)
+	reInline := regexp.MustCompile(`(\w+)\s*=\s*"([^"]*)"`)
+	var notes []string
+	pypi := false
+	active := false
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			m := reTable.FindStringSubmatch(strings.Trim(line, "[] "))
+			active = m != nil
+			if !active {
+				continue
+			}
+			notes = nil
+			pypi = m[3] == "pypi-dependencies"
+			if pypi {
+				notes = append(notes, "pip")
+			}
+			switch m[1] {
+			case "feature":
+				notes = append(notes, "feature: "+m[2])
+			case "target":
+				notes = append(notes, "platform: "+m[2])
+			}
+			if m[3] == "build-dependencies" || m[3] == "host-dependencies" {
+				notes = append(notes, "build")
+			}
+			continue
+		}
+		if !active {
+			continue
+		}
+		key, value, ok := tomlValue(line)
+		if !ok {
+			continue
+		}
+		version := value
+		entryNotes := append([]string{}, notes...)
+		if strings.HasPrefix(value, "{") {
+			opts := map[string]string{}
+			for _, om := range reInline.FindAllStringSubmatch(value, -1) {
+				opts[om[1]] = om[2]
+			}
+			version = opts["version"]
+			if ch := opts["channel"]; ch != "" {
+				entryNotes = append(entryNotes, "channel: "+ch)
+			}
+			for _, src := range []string{"git", "path", "url"} {
+				if v := opts[src]; v != "" {
+					entryNotes = append(entryNotes, src+": "+v)
+				}
+			}
+		}
+		dep := key
+		if version = strings.ReplaceAll(version, " ", ""); version != "" && version != "*" {
+			if strings.HasPrefix(version, "==") {
+				version = version[2:]
+			}
+			dep = fmt.Sprintf("%s@%s", key, version)
+		}
+		deps[annotateDep(dep, entryNotes...)] = struct{}{}
+	}
+
+	return setToSortedSlice(deps)
+}
+
+/************************************
+* Function Name: parsePixiLockDeps
+* Purpose: Extract locked packages from a pixi.lock. Supports both the
+*          "kind: conda|pypi" layout and the newer "conda: <url>" / "pypi: <url>"
+*          layout, where conda names are derived from the package filename.
+* Parameters: path string
+* Output: []string (format: name@version (channel: x | pip, sha256: x))
+*************************************/
+func parsePixiLockDeps(path string) []string {
+	s, err := readFileContent(path)
+	if err != nil {
+		return nil
+	}
+	doc := yamlMap(parseYAML(s))
+	deps := map[string]struct{}{}
+
+	for _, item := range yamlList(doc["packages"]) {
+		pkg := yamlMap(item)
+		if pkg == nil {
+			continue
+		}
+		kind := yamlString(pkg["kind"])
+		url := yamlString(pkg["url"])
+		if u := yamlString(pkg["conda"]); u != "" {
+			kind, url = "conda", u
+		} else if u := yamlString(pkg["pypi"]); u != "" {
+			kind, url = "pypi", u
+		}
+		name := yamlString(pkg["name"])
+		version := yamlString(pkg["version"])
+		if kind == "conda" && (name == "" || version == "") {
+			name, version = condaFilenameDep(url)
+		}
+		if name == "" {
+			continue
+		}
+		dep := name
+		if version != "" {
+			dep = fmt.Sprintf("%s@%s", name, version)
+		}
+		var notes []string
+		if kind == "pypi" {
+			notes = append(notes, "pip")
+		} else if ch := condaChannelFromURL(url); ch != "" {
+			notes = append(notes, "channel: "+ch)
+		}
+		if sum := yamlString(pkg["sha256"]); sum != "" {
+			notes = append(notes, "sha256: "+sum)
+		}
+		deps[annotateDep(dep, notes...)] = struct{}{}
+	}
+
+	return setToSortedSlice(deps)
+}
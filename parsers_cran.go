@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+/************************************
+* Function Name: parseDCFFields
+* Purpose: Parse a Debian control style file (R DESCRIPTION, dpkg status, ...)
+*          into one map per paragraph. Continuation lines are joined with a space.
+* Parameters: s string
+* Output: []map[string]string
+*************************************/
+func parseDCFFields(s string) []map[string]string {
+	var paras []map[string]string
+	current := map[string]string{}
+	last := ""
+	for _, line := range strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n") {
+		if strings.TrimSpace(line) == "" {
+			if len(current) > 0 {
+				paras = append(paras, current)
+				current = map[string]string{}
+			}
+			last = ""
+			continue
+		}
+		if (line[0] == ' ' || line[0] == '\t') && last != "" {
+			current[last] += " " + strings.TrimSpace(line)
+			continue
+		}
+		if parts := strings.SplitN(line, ":", 2); len(parts) == 2 {
+			last = strings.TrimSpace(parts[0])
+			current[last] = strings.TrimSpace(parts[1])
+		}
+	}
+	if len(current) > 0 {
+		paras = append(paras, current)
+	}
+	return paras
+}
+
+/************************************
+* Function Name: parseDescriptionDeps
+* Purpose: Extract Depends, Imports, LinkingTo and Suggests entries from an R
+*          package DESCRIPTION file. The R version requirement itself is skipped.
+* Parameters: path string
+* Output: []string (format: name@constraint (imports|depends|linkingto|suggests))
+*************************************/
+func parseDescriptionDeps(path string) []string {
+	s, err := readFileContent(path)
+	if err != nil {
+		return nil
+	}
+	paras := parseDCFFields(s)
+	if len(paras) == 0 {
+		return nil
+	}
+	fields := paras[0]
+	deps := map[string]struct{}{}
+
+	reEntry := regexp.MustCompile(`^([A-Za-z][\w.]*)\s*(?:\(([^)]*)\))?$`)
+	for _, field := range []string{"Depends", "Imports", "LinkingTo", "Suggests"} {
+		for _, item := range strings.Split(fields[field], ",") {
+			m := reEntry.FindStringSubmatch(strings.TrimSpace(item))
+			if len(m) < 2 || m[1] == "R" {
+				continue
+			}
+			dep := m[1]
+			if c := strings.ReplaceAll(m[2], " ", ""); c != "" {
+				dep = fmt.Sprintf("%s@%s", m[1], c)
+			}
+			deps[annotateDep(dep, strings.ToLower(field))] = struct{}{}
+		}
+	}
+
+	return setToSortedSlice(deps)
+}
+
+/************************************
+* Function Name: parseRenvLockDeps
+* Purpose: Extract locked packages from an renv.lock file. Packages installed
+*          from GitHub carry the remote repository and commit.
+* Parameters: path string
+* Output: []string (format: name@version (repository: x, git: url, revision: sha))
+*************************************/
+func parseRenvLockDeps(path string) []string {
+	s, err := readFileContent(path)
+	if err != nil {
+		return nil
+	}
+	var data struct {
+		Packages map[string]struct {
+			Package        string `json:"Package"`
+			Version        string `json:"Version"`
+			Source         string `json:"Source"`
+			Repository     string `json:"Repository"`
+			RemoteType     string `json:"RemoteType"`
+			RemoteHost     string `json:"RemoteHost"`
+			RemoteUsername string `json:"RemoteUsername"`
+			RemoteRepo     string `json:"RemoteRepo"`
+			RemoteSha      string `json:"RemoteSha"`
+		} `json:"Packages"`
+	}
+	if err := json.Unmarshal([]byte(s), &data); err != nil {
+		return nil
+	}
+	deps := map[string]struct{}{}
+	for key, p := range data.Packages {
+		name := p.Package
+		if name == "" {
+			name = key
+		}
+		dep := name
+		if p.Version != "" {
+			dep = fmt.Sprintf("%s@%s", name, p.Version)
+		}
+		var notes []string
+		switch {
+		case p.Source == "GitHub" || p.RemoteType == "github":
+			notes = append(notes, fmt.Sprintf("git: https://github.com/%s/%s.git", p.RemoteUsername, p.RemoteRepo))
+			if p.RemoteSha != "" {
+				notes = append(notes, "revision: "+p.RemoteSha)
+			}
+		case p.Source == "Local":
+			notes = append(notes, "source: local")
+		case p.Repository != "" && p.Repository != "CRAN":
+			notes = append(notes, "repository: "+p.Repository)
+		case p.Source == "Bioconductor":
+			notes = append(notes, "repository: Bioconductor")
+		}
+		deps[annotateDep(dep, notes...)] = struct{}{}
+	}
+	return setToSortedSlice(deps)
+}
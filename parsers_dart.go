@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+/************************************
+* Function Name: parsePubspecDeps
+* Purpose: Extract dependencies, dev_dependencies and dependency_overrides from a
+*          Dart/Flutter pubspec.yaml. Hosted, git, path and sdk sources are
+*          recognised; non-default sources are noted on the entry.
+* Parameters: path string
+* Output: []string (format: name@constraint (dev|override, source notes))
+*************************************/
+func parsePubspecDeps(path string) []string {
+	s, err := readFileContent(path)
+	if err != nil {
+		return nil
+	}
+	doc := yamlMap(parseYAML(s))
+	deps := map[string]struct{}{}
+
+	sections := []struct{ key, note string }{
+		{"dependencies", ""},
+		{"dev_dependencies", "dev"},
+		{"dependency_overrides", "override"},
+	}
+	for _, sec := range sections {
+		for name, spec := range yamlMap(doc[sec.key]) {
+			version := ""
+			notes := []string{sec.note}
+			switch v := spec.(type) {
+			case string:
+				version = v
+			case map[string]interface{}:
+				version = yamlString(v["version"])
+				if sdk := yamlString(v["sdk"]); sdk != "" {
+					notes = append(notes, "sdk: "+sdk)
+				}
+				if p := yamlString(v["path"]); p != "" {
+					notes = append(notes, "path: "+p)
+				}
+				switch g := v["git"].(type) {
+				case string:
+					notes = append(notes, "git: "+g)
+				case map[string]interface{}:
+					notes = append(notes, "git: "+yamlString(g["url"]))
+					if ref := yamlString(g["ref"]); ref != "" {
+						notes = append(notes, "ref: "+ref)
+					}
+					if sub := yamlString(g["path"]); sub != "" {
+						notes = append(notes, "git-path: "+sub)
+					}
+				}
+				switch h := v["hosted"].(type) {
+				case string:
+					notes = append(notes, "hosted: "+h)
+				case map[string]interface{}:
+					notes = append(notes, "hosted: "+yamlString(h["url"]))
+				}
+			}
+			dep := name
+			if version != "" {
+				dep = fmt.Sprintf("%s@%s", name, version)
+			}
+			deps[annotateDep(dep, notes...)] = struct{}{}
+		}
+	}
+
+	return setToSortedSlice(deps)
+}
+
+/************************************
+* Function Name: parsePubspecLockDeps
+* Purpose: Extract resolved packages from a pubspec.lock, classifying each as
+*          direct or transitive (and dev/override for direct entries).
+* Parameters: path string
+* Output: []string (format: name@version (direct|transitive[, dev][, source: x][, sha256: x]))
+*************************************/
+func parsePubspecLockDeps(path string) []string {
+	s, err := readFileContent(path)
+	if err != nil {
+		return nil
+	}
+	doc := yamlMap(parseYAML(s))
+	deps := map[string]struct{}{}
+
+	for name, entry := range yamlMap(doc["packages"]) {
+		pkg := yamlMap(entry)
+		if pkg == nil {
+			continue
+		}
+		dep := name
+		if version := yamlString(pkg["version"]); version != "" {
+			dep = fmt.Sprintf("%s@%s", name, version)
+		}
+
+		var notes []string
+		kind := yamlString(pkg["dependency"])
+		switch {
+		case strings.HasPrefix(kind, "direct"):
+			notes = append(notes, "direct")
+			if strings.HasSuffix(kind, "dev") {
+				notes = append(notes, "dev")
+			} else if strings.HasSuffix(kind, "overridden") {
+				notes = append(notes, "override")
+			}
+		case kind == "transitive":
+			notes = append(notes, "transitive")
+		}
+		if source := yamlString(pkg["source"]); source != "" && source != "hosted" {
+			notes = append(notes, "source: "+source)
+		}
+		if desc := yamlMap(pkg["description"]); desc != nil {
+			if ref := yamlString(desc["resolved-ref"]); ref != "" {
+				notes = append(notes, "revision: "+ref)
+			}
+			if sum := yamlString(desc["sha256"]); sum != "" {
+				notes = append(notes, "sha256: "+sum)
+			}
+		}
+		deps[annotateDep(dep, notes...)] = struct{}{}
+	}
+
+	return setToSortedSlice(deps)
+}
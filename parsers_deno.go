@@ -0,0 +1,443 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+/************************************
+* Function Name: stripJSONC
+* Purpose: Convert JSON with comments (deno.jsonc, bun.lock) into plain JSON by
+*          removing // and block comments and trailing commas outside strings.
+* Parameters: s string
+* Output: string
+*************************************/
+func stripJSONC(s string) string {
+	var b strings.Builder
+	inString := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case inString:
+			b.WriteByte(c)
+			if c == '\\' && i+1 < len(s) {
+				i++
+				b.WriteByte(s[i])
+			} else if c == '"' {
+				inString = false
+			}
+		case c == '"':
+			inString = true
+			b.WriteByte(c)
+		case c == '/' && i+1 < len(s) && s[i+1] == '/':
+			for i < len(s) && s[i] != '\n' {
+				i++
+			}
+			b.WriteByte('\n')
+		case c == '/' && i+1 < len(s) && s[i+1] == '*':
+			end := strings.Index(s[i+2:], "*/")
+			if end == -1 {
+				i = len(s)
+			} else {
+				i += end + 3
+			}
+		case c == ',':
+			// drop the comma when the next significant character closes a collection
+			j := i + 1
+			for j < len(s) && strings.ContainsRune(" \t\r\n", rune(s[j])) {
+				j++
+			}
+			if j < len(s) && (s[j] == '}' || s[j] == ']') {
+				continue
+			}
+			b.WriteByte(c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+/************************************
+* Function Name: splitRegistrySpecifier
+* Purpose: Split an npm:/jsr: specifier body such as "@scope/pkg@^1.0/sub/path"
+*          or "chalk@5" into package name and version.
+* Parameters: spec string (without the npm:/jsr: prefix)
+* Output: name string, version string
+*************************************/
+func splitRegistrySpecifier(spec string) (string, string) {
+	spec = strings.TrimPrefix(spec, "/")
+	name := spec
+	rest := ""
+	start := 0
+	if strings.HasPrefix(spec, "@") {
+		start = 1
+	}
+	if idx := strings.Index(spec[start:], "@"); idx != -1 {
+		name, rest = spec[:start+idx], spec[start+idx+1:]
+	} else if slash := strings.Index(spec[start:], "/"); slash != -1 && start == 0 {
+		name = spec[:slash]
+	}
+	// drop a trailing module path: name@version/sub/path
+	if strings.HasPrefix(name, "@") {
+		if parts := strings.SplitN(name, "/", 3); len(parts) == 3 {
+			name = parts[0] + "/" + parts[1]
+		}
+	}
+	if idx := strings.Index(rest, "/"); idx != -1 {
+		rest = rest[:idx]
+	}
+	return name, rest
+}
+
+/************************************
+* Function Name: denoRemoteModule
+* Purpose: Turn a deno.land URL (https://deno.land/std@0.200.0/..., or
+*          https://deno.land/x/oak@v12.0.0/...) into a dependency entry.
+* Parameters: u string
+* Output: string (empty for URLs that are not versioned deno.land modules)
+*************************************/
+func denoRemoteModule(u string) string {
+	reDenoLand := regexp.MustCompile(`^https://deno\.land/(x/)?([^/@]+)@([^/]+)`)
+	m := reDenoLand.FindStringSubmatch(u)
+	if len(m) < 4 {
+		return ""
+	}
+	return annotateDep(fmt.Sprintf("%s@%s", m[2], m[3]), "url: "+m[0])
+}
+
+/************************************
+* Function Name: classifyDenoSpecifier
+* Purpose: Route an import specifier to the ecosystem it belongs to: npm: into
+*          Node, jsr: into JSR, and deno.land URLs into Deno.
+* Parameters: spec string, out map[string]map[string]struct{}
+* Output: none (adds to out keyed by ecosystem display name)
+*************************************/
+func classifyDenoSpecifier(spec string, out map[string]map[string]struct{}) {
+	add := func(eco, dep string) {
+		if out[eco] == nil {
+			out[eco] = map[string]struct{}{}
+		}
+		out[eco][dep] = struct{}{}
+	}
+	for _, prefix := range []string{"npm:", "jsr:"} {
+		if !strings.HasPrefix(spec, prefix) {
+			continue
+		}
+		name, version := splitRegistrySpecifier(strings.TrimPrefix(spec, prefix))
+		if name == "" {
+			return
+		}
+		dep := name
+		if version != "" {
+			dep = fmt.Sprintf("%s@%s", name, version)
+		}
+		if prefix == "npm:" {
+			add("Node", dep)
+		} else {
+			add("JSR", dep)
+		}
+		return
+	}
+	if dep := denoRemoteModule(spec); dep != "" {
+		add("Deno", dep)
+	}
+}
+
+/************************************
+* Function Name: parseDenoJSONDeps
+* Purpose: Extract import-map entries from a deno.json / deno.jsonc (including an
+*          external importMap file and scopes). npm: specifiers are reported in
+*          the Node ecosystem and jsr: specifiers in the JSR ecosystem.
+* Parameters: path string
+* Output: map[string][]string (ecosystem -> name@version entries)
+*************************************/
+func parseDenoJSONDeps(path string) map[string][]string {
+	s, err := readFileContent(path)
+	if err != nil {
+		return nil
+	}
+	type importMap struct {
+		Imports   map[string]string            `json:"imports"`
+		Scopes    map[string]map[string]string `json:"scopes"`
+		ImportMap string                       `json:"importMap"`
+	}
+	var data importMap
+	if err := json.Unmarshal([]byte(stripJSONC(s)), &data); err != nil {
+		return nil
+	}
+	maps := []importMap{data}
+	if data.ImportMap != "" && !strings.Contains(data.ImportMap, "://") {
+		if ext, err := readFileContent(filepath.Join(filepath.Dir(path), data.ImportMap)); err == nil {
+			var extra importMap
+			if json.Unmarshal([]byte(stripJSONC(ext)), &extra) == nil {
+				maps = append(maps, extra)
+			}
+		}
+	}
+
+	sets := map[string]map[string]struct{}{}
+	for _, m := range maps {
+		for _, target := range m.Imports {
+			classifyDenoSpecifier(target, sets)
+		}
+		for _, scope := range m.Scopes {
+			for _, target := range scope {
+				classifyDenoSpecifier(target, sets)
+			}
+		}
+	}
+	out := map[string][]string{}
+	for eco, set := range sets {
+		out[eco] = setToSortedSlice(set)
+	}
+	return out
+}
+
+/************************************
+* Function Name: integrityNote
+* Purpose: Convert a Subresource Integrity string (sha512-<base64>) into a
+*          "sha512: <hex>" note.
+* Parameters: integrity string
+* Output: string (empty when integrity is not SRI)
+*************************************/
+func integrityNote(integrity string) string {
+	parts := strings.SplitN(integrity, "-", 2)
+	if len(parts) != 2 {
+		return ""
+	}
+	raw, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil || len(raw) == 0 {
+		return ""
+	}
+	return parts[0] + ": " + hex.EncodeToString(raw)
+}
+
+/************************************
+* Function Name: parseDenoLockDeps
+* Purpose: Extract resolved packages from a deno.lock (v3 and v4). npm packages
+*          go to Node, jsr packages to JSR and versioned deno.land remote modules
+*          to Deno, each with its integrity hash.
+* Parameters: path string
+* Output: map[string][]string (ecosystem -> name@version entries)
+*************************************/
+func parseDenoLockDeps(path string) map[string][]string {
+	s, err := readFileContent(path)
+	if err != nil {
+		return nil
+	}
+	type lockPackages struct {
+		Jsr map[string]struct {
+			Integrity string `json:"integrity"`
+		} `json:"jsr"`
+		Npm map[string]struct {
+			Integrity string `json:"integrity"`
+		} `json:"npm"`
+	}
+	var data struct {
+		lockPackages
+		Packages lockPackages      `json:"packages"`
+		Remote   map[string]string `json:"remote"`
+	}
+	if err := json.Unmarshal([]byte(s), &data); err != nil {
+		return nil
+	}
+
+	sets := map[string]map[string]struct{}{"Node": {}, "JSR": {}, "Deno": {}}
+	for _, pkgs := range []lockPackages{data.lockPackages, data.Packages} {
+		for key, p := range pkgs.Npm {
+			name, version := splitRegistrySpecifier(key)
+			// v4 keys may carry peer dependency suffixes after the version:
+			// react-dom@18.2.0_react@18.2.0 (names such as string_decoder keep theirs)
+			if idx := strings.Index(version, "_"); idx > 0 {
+				version = version[:idx]
+			}
+			sets["Node"][annotateDep(fmt.Sprintf("%s@%s", name, version), integrityNote(p.Integrity))] = struct{}{}
+		}
+		for key, p := range pkgs.Jsr {
+			name, version := splitRegistrySpecifier(key)
+			hash := ""
+			if p.Integrity != "" {
+				hash = "sha256: " + p.Integrity
+			}
+			sets["JSR"][annotateDep(fmt.Sprintf("%s@%s", name, version), hash)] = struct{}{}
+		}
+	}
+	for u := range data.Remote {
+		if dep := denoRemoteModule(u); dep != "" {
+			sets["Deno"][dep] = struct{}{}
+		}
+	}
+
+	out := map[string][]string{}
+	for eco, set := range sets {
+		if len(set) > 0 {
+			out[eco] = setToSortedSlice(set)
+		}
+	}
+	return out
+}
+
+/************************************
+* Function Name: parseBunLockDeps
+* Purpose: Extract resolved packages from a Bun text lockfile (bun.lock).
+*          Packages declared by a workspace are direct (dev when listed under
+*          devDependencies); workspace packages themselves are internal.
+* Parameters: path string
+* Output: []string (format: name@version (direct|transitive[, dev], sha512: x))
+*************************************/
+func parseBunLockDeps(path string) []string {
+	s, err := readFileContent(path)
+	if err != nil {
+		return nil
+	}
+	var data struct {
+		Workspaces map[string]struct {
+			Dependencies         map[string]string `json:"dependencies"`
+			DevDependencies      map[string]string `json:"devDependencies"`
+			OptionalDependencies map[string]string `json:"optionalDependencies"`
+			PeerDependencies     map[string]string `json:"peerDependencies"`
+		} `json:"workspaces"`
+		Packages map[string][]json.RawMessage `json:"packages"`
+	}
+	if err := json.Unmarshal([]byte(stripJSONC(s)), &data); err != nil {
+		return nil
+	}
+
+	direct := map[string]string{}
+	for _, ws := range data.Workspaces {
+		for _, group := range []map[string]string{ws.Dependencies, ws.OptionalDependencies, ws.PeerDependencies} {
+			for name := range group {
+				direct[name] = "direct"
+			}
+		}
+		for name := range ws.DevDependencies {
+			if _, ok := direct[name]; !ok {
+				direct[name] = "dev"
+			}
+		}
+	}
+
+	deps := map[string]struct{}{}
+	for key, entry := range data.Packages {
+		if len(entry) == 0 {
+			continue
+		}
+		var resolution string
+		if json.Unmarshal(entry[0], &resolution) != nil {
+			continue
+		}
+		name, version := splitRegistrySpecifier(resolution)
+		var notes []string
+		// workspace and git resolutions carry a path or URL after the name
+		if rest := strings.TrimPrefix(resolution, name+"@"); rest != resolution {
+			switch {
+			case strings.HasPrefix(rest, "workspace:"):
+				notes = append(notes, "internal", "path: "+strings.TrimPrefix(rest, "workspace:"))
+				version = ""
+			case strings.HasPrefix(rest, "github:") || strings.HasPrefix(rest, "git+"):
+				notes = append(notes, "git: "+rest)
+				version = ""
+			}
+		}
+		// nested installs are keyed parent/child; only top-level keys are direct
+		switch {
+		case key == name && direct[name] == "direct":
+			notes = append(notes, "direct")
+		case key == name && direct[name] == "dev":
+			notes = append(notes, "direct", "dev")
+		default:
+			notes = append(notes, "transitive")
+		}
+		if len(entry) >= 4 {
+			var integrity string
+			if json.Unmarshal(entry[len(entry)-1], &integrity) == nil {
+				notes = append(notes, integrityNote(integrity))
+			}
+		}
+		dep := name
+		if version != "" {
+			dep = fmt.Sprintf("%s@%s", name, version)
+		}
+		deps[annotateDep(dep, notes...)] = struct{}{}
+	}
+
+	return setToSortedSlice(deps)
+}
+
+/************************************
+* Function Name: bunLockEdges
+* Purpose: Read the dependency graph of a Bun text lockfile (bun.lock). A
+*          package's dependency resolves to the nested install keyed
+*          parent/child when there is one, as Bun installs it, else to the
+*          entry of the enclosing scope up to the top-level package.
+* Parameters: path string
+* Output: map[string][]string (name@version -> name@version of its dependencies)
+*************************************/
+func bunLockEdges(path string) map[string][]string {
+	s, err := readFileContent(path)
+	if err != nil {
+		return nil
+	}
+	var data struct {
+		Packages map[string][]json.RawMessage `json:"packages"`
+	}
+	if err := json.Unmarshal([]byte(stripJSONC(s)), &data); err != nil {
+		return nil
+	}
+
+	locked := map[string]string{} // lockfile key -> name@version
+	for key, entry := range data.Packages {
+		var resolution string
+		if len(entry) == 0 || json.Unmarshal(entry[0], &resolution) != nil {
+			continue
+		}
+		name, version := splitRegistrySpecifier(resolution)
+		locked[key] = name
+		if version != "" && !strings.Contains(version, ":") {
+			locked[key] = fmt.Sprintf("%s@%s", name, version)
+		}
+	}
+
+	edges := map[string][]string{}
+	for key, entry := range data.Packages {
+		parent, ok := locked[key]
+		if !ok {
+			continue
+		}
+		for _, raw := range entry[1:] {
+			var info struct {
+				Dependencies         map[string]string `json:"dependencies"`
+				OptionalDependencies map[string]string `json:"optionalDependencies"`
+				PeerDependencies     map[string]string `json:"peerDependencies"`
+			}
+			if json.Unmarshal(raw, &info) != nil {
+				continue
+			}
+			for _, group := range []map[string]string{info.Dependencies, info.OptionalDependencies, info.PeerDependencies} {
+				for child := range group {
+					for scope := key; ; {
+						if dep, ok := locked[scope+"/"+child]; ok {
+							edges[parent] = append(edges[parent], dep)
+							break
+						}
+						idx := strings.LastIndex(scope, "/")
+						if idx == -1 {
+							if dep, ok := locked[child]; ok {
+								edges[parent] = append(edges[parent], dep)
+							}
+							break
+						}
+						scope = scope[:idx]
+					}
+				}
+			}
+		}
+	}
+	return edges
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseDenoLockDeps(t *testing.T) {
+	tests := []struct {
+		name string
+		lock string
+		want map[string][]string
+	}{
+		{"v4 npm keys", `{"version":"4","npm":{
+			"string_decoder@1.3.0":{},
+			"react-dom@18.2.0_react@18.2.0":{},
+			"@types/node@20.11.0":{},
+			"@scope/with_underscore@1.0.0_@types+node@20.11.0":{}}}`,
+			map[string][]string{"Node": {"@scope/with_underscore@1.0.0", "@types/node@20.11.0", "react-dom@18.2.0", "string_decoder@1.3.0"}}},
+		{"v3 packages", `{"version":"3","packages":{
+			"npm":{"chalk@5.3.0":{}},
+			"jsr":{"@std/path@1.0.0":{"integrity":"abc"}}}}`,
+			map[string][]string{"Node": {"chalk@5.3.0"}, "JSR": {"@std/path@1.0.0 (sha256: abc)"}}},
+		{"invalid json", `{`, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "deno.lock")
+			if err := os.WriteFile(path, []byte(tt.lock), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if got := parseDenoLockDeps(path); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseDenoLockDeps() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
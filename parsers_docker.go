@@ -0,0 +1,464 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+/************************************
+* Function Name: expandDockerVars
+* Purpose: Substitute $VAR, ${VAR}, ${VAR:-default} and ${VAR:+alt} references
+*          using the given variables. Unknown variables are left in place.
+* Parameters: s string, vars map[string]string
+* Output: string
+*************************************/
+func expandDockerVars(s string, vars map[string]string) string {
+	reVar := regexp.MustCompile(`\$\{(\w+)(?::?([-+])([^}]*))?\}|\$(\w+)`)
+	return reVar.ReplaceAllStringFunc(s, func(ref string) string {
+		m := reVar.FindStringSubmatch(ref)
+		name := m[1] + m[4]
+		value, ok := vars[name]
+		switch m[2] {
+		case "-":
+			if !ok || value == "" {
+				return m[3]
+			}
+		case "+":
+			if ok && value != "" {
+				return m[3]
+			}
+			return ""
+		}
+		if !ok {
+			return ref
+		}
+		return value
+	})
+}
+
+/************************************
+* Function Name: splitImageRef
+* Purpose: Split a container image reference (registry/repo:tag@sha256:...) into
+*          repository, tag and digest.
+* Parameters: ref string
+* Output: name string, tag string, digest string
+*************************************/
+func splitImageRef(ref string) (string, string, string) {
+	name, digest := ref, ""
+	if idx := strings.Index(ref, "@"); idx != -1 {
+		name, digest = ref[:idx], ref[idx+1:]
+	}
+	tag := ""
+	if idx := strings.LastIndex(name, ":"); idx != -1 && idx > strings.LastIndex(name, "/") {
+		name, tag = name[:idx], name[idx+1:]
+	}
+	return name, tag, digest
+}
+
+/************************************
+* Function Name: imageDep
+* Purpose: Turn a container image reference into a dependency entry noted as
+*          image. Unresolved variables, templates and the empty "scratch"
+*          image yield "".
+* Parameters: ref string, notes ...string
+* Output: string (format: repo@tag (image, digest: sha256:x, ...))
+*************************************/
+func imageDep(ref string, notes ...string) string {
+	ref = strings.Trim(strings.TrimSpace(ref), `"'`)
+	if ref == "" || strings.EqualFold(ref, "scratch") || strings.Contains(ref, "$") || strings.Contains(ref, "{{") {
+		return ""
+	}
+	name, tag, digest := splitImageRef(ref)
+	dep := name
+	if tag != "" {
+		dep = fmt.Sprintf("%s@%s", name, tag)
+	}
+	all := []string{"image"}
+	if digest != "" {
+		all = append(all, "digest: "+digest)
+	}
+	return annotateDep(dep, append(all, notes...)...)
+}
+
+/************************************
+* Function Name: imageDistro
+* Purpose: Guess the Linux distribution of a base image from its reference, so
+*          that apt and apk installs can be attributed to it.
+* Parameters: ref string
+* Output: string (debian, ubuntu, alpine or "")
+*************************************/
+func imageDistro(ref string) string {
+	ref = strings.ToLower(ref)
+	for _, d := range []string{"alpine", "ubuntu", "debian"} {
+		if strings.Contains(ref, d) {
+			return d
+		}
+	}
+	for _, codename := range []string{"bookworm", "bullseye", "buster", "trixie"} {
+		if strings.Contains(ref, codename) {
+			return "debian"
+		}
+	}
+	for _, codename := range []string{"jammy", "focal", "noble"} {
+		if strings.Contains(ref, codename) {
+			return "ubuntu"
+		}
+	}
+	return ""
+}
+
+/************************************
+* Function Name: parseShellInstalls
+* Purpose: Find package installs in a shell command line: apt-get/apt install,
+*          apk add, pip install and npm install. Each package is noted with its
+*          installer (apt, apk, pip, npm); system packages also carry the
+*          distribution when it is known.
+* Parameters: cmd string, distro string, notes ...string (added to every package)
+* Output: []string (format: name@version (apt|apk|pip|npm, distro: x, ...))
+*************************************/
+func parseShellInstalls(cmd, distro string, notes ...string) []string {
+	var deps []string
+	// options that consume the following argument
+	argFlags := map[string]bool{
+		"-o": true, "-t": true, "--target-release": true, // apt
+		"-X": true, "--repository": true, "--virtual": true, "--arch": true, "--root": true, "-p": true, // apk
+		"-r": true, "--requirement": true, "-c": true, "--constraint": true, "-e": true, "--editable": true,
+		"-i": true, "--index-url": true, "--extra-index-url": true, "-f": true, "--find-links": true,
+		"--target": true, "--prefix": true, "--trusted-host": true, "--platform": true, "--python-version": true, // pip
+		"--registry": true, // npm
+	}
+	rePip := regexp.MustCompile(`^([A-Za-z0-9][\w.\-]*)(?:\[[^\]]*\])?\s*(.*)$`)
+
+	for _, part := range regexp.MustCompile(`&&|\|\||;|\|`).Split(cmd, -1) {
+		var fields []string
+		for _, f := range strings.Fields(part) {
+			fields = append(fields, strings.Trim(f, `"'`))
+		}
+		// skip sudo and leading VAR=value assignments
+		for len(fields) > 0 && (fields[0] == "sudo" || (strings.Contains(fields[0], "=") && !strings.HasPrefix(fields[0], "-"))) {
+			fields = fields[1:]
+		}
+		if len(fields) < 2 {
+			continue
+		}
+		installer := ""
+		args := []string{}
+		tool := filepath.Base(fields[0])
+		switch {
+		case (tool == "apt-get" || tool == "apt" || tool == "aptitude") && containsField(fields, "install"):
+			installer, args = "apt", fieldsAfter(fields, "install")
+		case tool == "apk" && containsField(fields, "add"):
+			installer, args = "apk", fieldsAfter(fields, "add")
+		case strings.HasPrefix(tool, "pip") && containsField(fields, "install"):
+			installer, args = "pip", fieldsAfter(fields, "install")
+		case strings.HasPrefix(tool, "python") && len(fields) > 3 && fields[1] == "-m" && strings.HasPrefix(fields[2], "pip") && fields[3] == "install":
+			installer, args = "pip", fields[4:]
+		case tool == "npm" && (fields[1] == "install" || fields[1] == "i" || fields[1] == "add"):
+			installer, args = "npm", fields[2:]
+		default:
+			continue
+		}
+
+		for i := 0; i < len(args); i++ {
+			arg := args[i]
+			if strings.HasPrefix(arg, "-") {
+				if argFlags[arg] {
+					i++
+				}
+				continue
+			}
+			// local paths, URLs and archives are not registry packages
+			if strings.ContainsAny(arg, "$") || strings.Contains(arg, "://") || strings.HasPrefix(arg, ".") ||
+				strings.HasPrefix(arg, "/") || strings.HasSuffix(arg, ".whl") || strings.HasSuffix(arg, ".tar.gz") || strings.HasSuffix(arg, ".deb") {
+				continue
+			}
+			dep := ""
+			switch installer {
+			case "apt":
+				// name=version, name/release
+				name, version := arg, ""
+				if idx := strings.Index(arg, "="); idx != -1 {
+					name, version = arg[:idx], arg[idx+1:]
+				}
+				name = strings.SplitN(name, "/", 2)[0]
+				dep = name
+				if version != "" {
+					dep = fmt.Sprintf("%s@%s", name, version)
+				}
+			case "apk":
+				// name=version, name~version, name>=version
+				m := regexp.MustCompile(`^([^=~<>]+)(=|~|[<>]=?)?(.*)$`).FindStringSubmatch(arg)
+				dep = m[1]
+				switch {
+				case m[2] == "=" && m[3] != "":
+					dep = fmt.Sprintf("%s@%s", m[1], m[3])
+				case m[2] != "" && m[3] != "":
+					dep = fmt.Sprintf("%s@%s%s", m[1], m[2], m[3])
+				}
+			case "pip":
+				m := rePip.FindStringSubmatch(arg)
+				if m == nil {
+					continue
+				}
+				version := strings.ReplaceAll(m[2], " ", "")
+				if strings.HasPrefix(version, "==") {
+					version = version[2:]
+				}
+				dep = m[1]
+				if version != "" {
+					dep = fmt.Sprintf("%s@%s", m[1], version)
+				}
+			case "npm":
+				if strings.Contains(arg, ":") || (strings.Contains(arg, "/") && !strings.HasPrefix(arg, "@")) {
+					continue // git:, github shorthand, file: ...
+				}
+				name, version := splitRegistrySpecifier(arg)
+				dep = name
+				if version != "" {
+					dep = fmt.Sprintf("%s@%s", name, version)
+				}
+			}
+			if dep == "" {
+				continue
+			}
+			all := []string{installer}
+			if distro != "" && (installer == "apt" || installer == "apk") {
+				all = append(all, "distro: "+distro)
+			}
+			deps = append(deps, annotateDep(dep, append(all, notes...)...))
+		}
+	}
+	return deps
+}
+
+func containsField(fields []string, word string) bool {
+	for _, f := range fields {
+		if f == word {
+			return true
+		}
+	}
+	return false
+}
+
+func fieldsAfter(fields []string, word string) []string {
+	for i, f := range fields {
+		if f == word {
+			return fields[i+1:]
+		}
+	}
+	return nil
+}
+
+/************************************
+* Function Name: splitSystemInstalls
+* Purpose: Separate the packages installed by RUN lines (noted apt, apk, pip or
+*          npm) from the images of a Dockerfile, so they are reported under the
+*          System ecosystem rather than next to the images.
+* Parameters: deps []string
+* Output: images []string, installs []string
+*************************************/
+func splitSystemInstalls(deps []string) ([]string, []string) {
+	images, installs := []string{}, []string{}
+	for _, dep := range deps {
+		_, _, notes := splitDep(dep)
+		if hasNote(notes, "apt") || hasNote(notes, "apk") || hasNote(notes, "pip") || hasNote(notes, "npm") {
+			installs = append(installs, dep)
+		} else {
+			images = append(images, dep)
+		}
+	}
+	return images, installs
+}
+
+/************************************
+* Function Name: parseDockerfileDeps
+* Purpose: Extract base images and installed packages from a Dockerfile or
+*          Containerfile. ARG defaults are substituted, FROM lines naming an
+*          earlier stage are followed instead of reported, COPY --from images
+*          are included and RUN lines are scanned for package installs.
+* Parameters: path string
+* Output: []string (format: repo@tag (image, digest: x, stage: x) or
+*         name@version (apt|apk|pip|npm, distro: x, stage: x))
+*************************************/
+func parseDockerfileDeps(path string) []string {
+	s, err := readFileContent(path)
+	if err != nil {
+		return nil
+	}
+	deps := map[string]struct{}{}
+
+	// join continuation lines and drop comments
+	var lines []string
+	current := ""
+	for _, line := range strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if strings.HasSuffix(trimmed, "\\") {
+			current += strings.TrimSuffix(trimmed, "\\") + " "
+			continue
+		}
+		current += trimmed
+		if strings.TrimSpace(current) != "" {
+			lines = append(lines, strings.TrimSpace(current))
+		}
+		current = ""
+	}
+
+	globalArgs := map[string]string{}
+	stageDistro := map[string]string{} // stage alias -> distro
+	var vars map[string]string
+	stage, distro := "", ""
+	inStage := false
+
+	for _, line := range lines {
+		parts := strings.SplitN(line, " ", 2)
+		instr := strings.ToUpper(parts[0])
+		rest := ""
+		if len(parts) == 2 {
+			rest = strings.TrimSpace(parts[1])
+		}
+		switch instr {
+		case "ARG":
+			for _, a := range strings.Fields(rest) {
+				kv := strings.SplitN(a, "=", 2)
+				if !inStage {
+					if len(kv) == 2 {
+						globalArgs[kv[0]] = strings.Trim(kv[1], `"'`)
+					}
+					continue
+				}
+				if len(kv) == 2 {
+					vars[kv[0]] = expandDockerVars(strings.Trim(kv[1], `"'`), vars)
+				} else if v, ok := globalArgs[kv[0]]; ok {
+					vars[kv[0]] = v
+				}
+			}
+		case "ENV":
+			if !inStage {
+				continue
+			}
+			if !strings.Contains(strings.Fields(rest + " ")[0], "=") {
+				// legacy "ENV KEY value" form
+				if kv := strings.SplitN(rest, " ", 2); len(kv) == 2 {
+					vars[kv[0]] = expandDockerVars(strings.Trim(strings.TrimSpace(kv[1]), `"'`), vars)
+				}
+				continue
+			}
+			for _, a := range strings.Fields(rest) {
+				if kv := strings.SplitN(a, "=", 2); len(kv) == 2 {
+					vars[kv[0]] = expandDockerVars(strings.Trim(kv[1], `"'`), vars)
+				}
+			}
+		case "FROM":
+			fields := strings.Fields(rest)
+			for len(fields) > 0 && strings.HasPrefix(fields[0], "--") {
+				fields = fields[1:] // --platform=...
+			}
+			if len(fields) == 0 {
+				continue
+			}
+			inStage = true
+			vars = map[string]string{}
+			ref := expandDockerVars(fields[0], globalArgs)
+			stage = ""
+			if len(fields) >= 3 && strings.EqualFold(fields[1], "as") {
+				stage = fields[2]
+			}
+			if d, ok := stageDistro[strings.ToLower(ref)]; ok {
+				// built on an earlier stage
+				distro = d
+			} else {
+				distro = imageDistro(ref)
+				stageNote := ""
+				if stage != "" {
+					stageNote = "stage: " + stage
+				}
+				if dep := imageDep(ref, stageNote); dep != "" {
+					deps[dep] = struct{}{}
+				}
+			}
+			if stage != "" {
+				stageDistro[strings.ToLower(stage)] = distro
+			}
+		case "COPY":
+			for _, f := range strings.Fields(rest) {
+				if !strings.HasPrefix(f, "--from=") {
+					continue
+				}
+				from := expandDockerVars(strings.TrimPrefix(f, "--from="), vars)
+				if _, ok := stageDistro[strings.ToLower(from)]; ok || regexp.MustCompile(`^\d+$`).MatchString(from) {
+					continue
+				}
+				if dep := imageDep(from, "copy"); dep != "" {
+					deps[dep] = struct{}{}
+				}
+			}
+		case "RUN":
+			if !inStage {
+				continue
+			}
+			cmd := expandDockerVars(rest, vars)
+			for strings.HasPrefix(cmd, "--") {
+				// RUN --mount=... --network=...
+				if idx := strings.Index(cmd, " "); idx != -1 {
+					cmd = strings.TrimSpace(cmd[idx:])
+				} else {
+					cmd = ""
+				}
+			}
+			if strings.HasPrefix(cmd, "[") {
+				// exec form: ["apt-get", "install", "-y", "curl"]
+				cmd = strings.NewReplacer("[", "", "]", "", ",", " ").Replace(cmd)
+			}
+			notes := []string{}
+			if stage != "" {
+				notes = append(notes, "stage: "+stage)
+			}
+			for _, dep := range parseShellInstalls(cmd, distro, notes...) {
+				deps[dep] = struct{}{}
+			}
+		}
+	}
+
+	return setToSortedSlice(deps)
+}
+
+/************************************
+* Function Name: parseComposeDeps
+* Purpose: Extract service images from a docker-compose / compose file.
+*          ${VAR} references are resolved from the .env file next to it and
+*          their inline defaults.
+* Parameters: path string
+* Output: []string (format: repo@tag (image, digest: x, service: name))
+*************************************/
+func parseComposeDeps(path string) []string {
+	s, err := readFileContent(path)
+	if err != nil {
+		return nil
+	}
+	vars := map[string]string{}
+	if env, err := readFileContent(filepath.Join(filepath.Dir(path), ".env")); err == nil {
+		for _, line := range strings.Split(env, "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			if kv := strings.SplitN(strings.TrimPrefix(line, "export "), "=", 2); len(kv) == 2 {
+				vars[strings.TrimSpace(kv[0])] = strings.Trim(strings.TrimSpace(kv[1]), `"'`)
+			}
+		}
+	}
+	deps := map[string]struct{}{}
+	doc := yamlMap(parseYAML(s))
+	for name, svc := range yamlMap(doc["services"]) {
+		ref := expandDockerVars(yamlString(yamlMap(svc)["image"]), vars)
+		if dep := imageDep(ref, "service: "+name); dep != "" {
+			deps[dep] = struct{}{}
+		}
+	}
+	return setToSortedSlice(deps)
+}
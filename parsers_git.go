@@ -0,0 +1,110 @@
+package main
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// gitSubmodule is one [submodule "name"] section of a .gitmodules file.
+type gitSubmodule struct {
+	name   string
+	path   string
+	url    string
+	branch string
+}
+
+/************************************
+* Function Name: readGitmodules
+* Purpose: Parse the [submodule "name"] sections of a .gitmodules file.
+* Parameters: path string
+* Output: []gitSubmodule
+*************************************/
+func readGitmodules(path string) []gitSubmodule {
+	s, err := readFileContent(path)
+	if err != nil {
+		return nil
+	}
+	var subs []gitSubmodule
+	var current *gitSubmodule
+	reSection := regexp.MustCompile(`^\[submodule\s+"([^"]+)"\]$`)
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			current = nil
+			if m := reSection.FindStringSubmatch(line); m != nil {
+				subs = append(subs, gitSubmodule{name: m[1]})
+				current = &subs[len(subs)-1]
+			}
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		value := strings.Trim(strings.TrimSpace(kv[1]), `"`)
+		switch strings.ToLower(strings.TrimSpace(kv[0])) {
+		case "path":
+			current.path = value
+		case "url":
+			current.url = value
+		case "branch":
+			current.branch = value
+		}
+	}
+	return subs
+}
+
+/************************************
+* Function Name: submoduleDirs
+* Purpose: List the absolute directories of the submodules declared in the
+*          top-level .gitmodules of a checkout.
+* Parameters: root string
+* Output: []string
+*************************************/
+func submoduleDirs(root string) []string {
+	var dirs []string
+	for _, sub := range readGitmodules(filepath.Join(root, ".gitmodules")) {
+		if sub.path != "" {
+			dirs = append(dirs, filepath.Join(root, filepath.FromSlash(sub.path)))
+		}
+	}
+	return dirs
+}
+
+/************************************
+* Function Name: parseGitmodulesDeps
+* Purpose: Report each submodule of a .gitmodules file with its URL, tracked
+*          branch and the commit pinned in the git index (git ls-tree).
+* Parameters: path string
+* Output: []string (format: name (git: url, revision: sha, branch: x, submodule: dir))
+*************************************/
+func parseGitmodulesDeps(path string) []string {
+	deps := map[string]struct{}{}
+	repoDir := filepath.Dir(path)
+	for _, sub := range readGitmodules(path) {
+		if sub.url == "" {
+			continue
+		}
+		url := strings.TrimSuffix(sub.url, "/")
+		notes := []string{"git: " + url}
+		// the git index is only available for checkouts on disk
+		if _, onDisk := sourceFS.(hostFS); onDisk {
+			if sha := submoduleCommit(repoDir, sub.path); sha != "" {
+				notes = append(notes, "revision: "+sha)
+			}
+		}
+		if sub.branch != "" {
+			notes = append(notes, "branch: "+sub.branch)
+		}
+		notes = append(notes, "submodule: "+sub.path)
+		deps[annotateDep(swiftPackageIdentity(url), notes...)] = struct{}{}
+	}
+	return setToSortedSlice(deps)
+}
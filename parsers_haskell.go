@@ -0,0 +1,205 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+/************************************
+* Function Name: haskellDep
+* Purpose: Turn a Cabal dependency ("text ^>=2.0", "any.aeson ==2.0.3.0") into
+*          a dependency entry. Constraints are kept with whitespace removed; an
+*          exact ==version constraint becomes the version itself.
+* Parameters: item string, notes ...string
+* Output: string (empty when item has no package name)
+*************************************/
+func haskellDep(item string, notes ...string) string {
+	item = strings.TrimSpace(item)
+	reItem := regexp.MustCompile(`^(?:any\.)?([A-Za-z][\w\-]*(?::[\w\-]+)?)\s*(.*)$`)
+	m := reItem.FindStringSubmatch(item)
+	if len(m) < 3 {
+		return ""
+	}
+	name := strings.TrimSpace(m[1])
+	constraint := strings.ReplaceAll(strings.TrimSpace(m[2]), " ", "")
+	switch {
+	case constraint == "" || constraint == "-any":
+		return annotateDep(name, notes...)
+	case constraint == "installed":
+		return annotateDep(name, append(notes, "installed")...)
+	case strings.HasPrefix(constraint, "==") && !strings.ContainsAny(constraint[2:], "*|&<>="):
+		constraint = constraint[2:]
+	}
+	return annotateDep(fmt.Sprintf("%s@%s", name, constraint), notes...)
+}
+
+/************************************
+* Function Name: parseCabalFileDeps
+* Purpose: Extract build-depends from every stanza of a .cabal file. Test suites
+*          and benchmarks are noted; references to the package's own library
+*          are marked internal.
+* Parameters: path string
+* Output: []string (format: name@constraint (test | benchmark | internal))
+*************************************/
+func parseCabalFileDeps(path string) []string {
+	s, err := readFileContent(path)
+	if err != nil {
+		return nil
+	}
+	deps := map[string]struct{}{}
+
+	pkgName := ""
+	reName := regexp.MustCompile(`(?mi)^name\s*:\s*(\S+)`)
+	if m := reName.FindStringSubmatch(s); len(m) > 1 {
+		pkgName = m[1]
+	}
+
+	reStanza := regexp.MustCompile(`(?i)^(library|executable|test-suite|benchmark|foreign-library)\b`)
+	reField := regexp.MustCompile(`(?i)^(\s*)build-depends\s*:(.*)$`)
+	lines := strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+	stanza := ""
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if idx := strings.Index(line, "--"); idx != -1 {
+			line = line[:idx]
+		}
+		if m := reStanza.FindStringSubmatch(line); len(m) > 1 {
+			stanza = strings.ToLower(m[1])
+			continue
+		}
+		m := reField.FindStringSubmatch(line)
+		if len(m) < 3 {
+			continue
+		}
+		// the field continues on lines indented deeper than the field name
+		value := m[2]
+		indent := len(m[1])
+		for i+1 < len(lines) {
+			next := lines[i+1]
+			if strings.TrimSpace(next) != "" && len(next)-len(strings.TrimLeft(next, " \t")) <= indent {
+				break
+			}
+			if idx := strings.Index(next, "--"); idx != -1 {
+				next = next[:idx]
+			}
+			value += " " + next
+			i++
+		}
+		note := ""
+		switch stanza {
+		case "test-suite":
+			note = "test"
+		case "benchmark":
+			note = "benchmark"
+		}
+		for _, item := range strings.Split(value, ",") {
+			item = strings.TrimSpace(item)
+			if item == "" {
+				continue
+			}
+			name := strings.Fields(item)[0]
+			internal := ""
+			if name == pkgName || strings.HasPrefix(name, pkgName+":") {
+				internal = "internal"
+			}
+			if dep := haskellDep(item, note, internal); dep != "" {
+				deps[dep] = struct{}{}
+			}
+		}
+	}
+
+	return setToSortedSlice(deps)
+}
+
+/************************************
+* Function Name: parseStackYamlDeps
+* Purpose: Extract extra-deps from a Stack stack.yaml. Hackage entries
+*          (name-version[@sha256:hash,size]) are split into name and version;
+*          git/github entries carry the pinned commit.
+* Parameters: path string
+* Output: []string (format: name@version (sha256: x) or url (git: url, revision: sha))
+*************************************/
+func parseStackYamlDeps(path string) []string {
+	s, err := readFileContent(path)
+	if err != nil {
+		return nil
+	}
+	doc := yamlMap(parseYAML(s))
+	deps := map[string]struct{}{}
+
+	rePkg := regexp.MustCompile(`^([A-Za-z][\w\-]*?)-(\d[\d.]*)(?:@(sha256:([0-9a-f]+)|rev:\d+))?`)
+	for _, item := range yamlList(doc["extra-deps"]) {
+		switch v := item.(type) {
+		case string:
+			m := rePkg.FindStringSubmatch(v)
+			if len(m) < 3 {
+				continue
+			}
+			hash := ""
+			if m[4] != "" {
+				hash = "sha256: " + m[4]
+			}
+			deps[annotateDep(fmt.Sprintf("%s@%s", m[1], m[2]), hash)] = struct{}{}
+		case map[string]interface{}:
+			url := yamlString(v["git"])
+			if gh := yamlString(v["github"]); gh != "" {
+				url = "https://github.com/" + gh + ".git"
+			}
+			if url == "" {
+				continue
+			}
+			commit := ""
+			if c := yamlString(v["commit"]); c != "" {
+				commit = "revision: " + c
+			}
+			deps[annotateDep(swiftPackageIdentity(url), "git: "+url, commit)] = struct{}{}
+		}
+	}
+
+	return setToSortedSlice(deps)
+}
+
+/************************************
+* Function Name: parseCabalFreezeDeps
+* Purpose: Extract pinned versions from the constraints field of a
+*          cabal.project.freeze file. Flag-only constraints are skipped.
+* Parameters: path string
+* Output: []string (format: name@version)
+*************************************/
+func parseCabalFreezeDeps(path string) []string {
+	s, err := readFileContent(path)
+	if err != nil {
+		return nil
+	}
+	deps := map[string]struct{}{}
+
+	value := ""
+	inConstraints := false
+	for _, line := range strings.Split(s, "\n") {
+		if strings.HasPrefix(line, "constraints:") {
+			inConstraints = true
+			value += strings.TrimPrefix(line, "constraints:")
+			continue
+		}
+		if inConstraints {
+			if strings.TrimSpace(line) != "" && !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t") {
+				inConstraints = false
+				continue
+			}
+			value += " " + line
+		}
+	}
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		fields := strings.Fields(item)
+		if len(fields) < 2 || strings.HasPrefix(fields[1], "+") || strings.HasPrefix(fields[1], "-") {
+			continue // flag assignments such as "aeson -ordered-keymap"
+		}
+		if dep := haskellDep(item); dep != "" {
+			deps[dep] = struct{}{}
+		}
+	}
+
+	return setToSortedSlice(deps)
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+/************************************
+* Function Name: parseChartDeps
+* Purpose: Extract chart dependencies from a Helm Chart.yaml or Chart.lock
+*          (name, version, repository). file:// repositories are local charts.
+* Parameters: path string
+* Output: []string (format: name@version (repository: url | path: dir, alias: x))
+*************************************/
+func parseChartDeps(path string) []string {
+	s, err := readFileContent(path)
+	if err != nil {
+		return nil
+	}
+	doc := yamlMap(parseYAML(s))
+	deps := map[string]struct{}{}
+
+	for _, item := range yamlList(doc["dependencies"]) {
+		d := yamlMap(item)
+		name := yamlString(d["name"])
+		if name == "" {
+			continue
+		}
+		dep := name
+		if v := strings.ReplaceAll(yamlString(d["version"]), " ", ""); v != "" {
+			dep = fmt.Sprintf("%s@%s", name, v)
+		}
+		var notes []string
+		repo := yamlString(d["repository"])
+		switch {
+		case strings.HasPrefix(repo, "file://"):
+			notes = append(notes, "path: "+strings.TrimPrefix(repo, "file://"))
+		case repo != "":
+			notes = append(notes, "repository: "+repo)
+		}
+		if alias := yamlString(d["alias"]); alias != "" {
+			notes = append(notes, "alias: "+alias)
+		}
+		deps[annotateDep(dep, notes...)] = struct{}{}
+	}
+
+	return setToSortedSlice(deps)
+}
+
+/************************************
+* Function Name: isHelmValuesFile
+* Purpose: Report whether a file is a chart's values file (values.yaml,
+*          values-prod.yaml, values.prod.yaml, ...) sitting next to a Chart.yaml.
+* Parameters: path string
+* Output: bool
+*************************************/
+func isHelmValuesFile(path string) bool {
+	name := strings.ToLower(filepath.Base(path))
+	if !regexp.MustCompile(`^values([.\-][\w.\-]+)?\.ya?ml$`).MatchString(name) {
+		return false
+	}
+	return pathExists(filepath.Join(filepath.Dir(path), "Chart.yaml"))
+}
+
+/************************************
+* Function Name: isKubernetesManifest
+* Purpose: Report whether a YAML file declares a Kubernetes workload that runs
+*          containers (Deployment, StatefulSet, DaemonSet, CronJob, Job, Pod, ...).
+* Parameters: path string
+* Output: bool
+*************************************/
+func isKubernetesManifest(path string) bool {
+	s, err := readFileContent(path)
+	if err != nil {
+		return false
+	}
+	reKind := regexp.MustCompile(`(?m)^kind:\s*["']?(Deployment|StatefulSet|DaemonSet|CronJob|Job|Pod|ReplicaSet|ReplicationController)["']?\s*$`)
+	return reKind.MatchString(s) && strings.Contains(s, "apiVersion:")
+}
+
+/************************************
+* Function Name: collectImages
+* Purpose: Walk a YAML tree and collect every "image" value. Plain strings are
+*          image references; maps use the common chart layout of
+*          registry / repository / tag / digest keys.
+* Parameters: v interface{}, keyPath string, add func(ref, keyPath string)
+* Output: none
+*************************************/
+func collectImages(v interface{}, keyPath string, add func(ref, keyPath string)) {
+	switch node := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(node))
+		for k := range node {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			child := k
+			if keyPath != "" {
+				child = keyPath + "." + k
+			}
+			if k == "image" {
+				if ref := yamlString(node[k]); ref != "" {
+					add(ref, child)
+					continue
+				}
+				if m := yamlMap(node[k]); yamlString(m["repository"]) != "" {
+					ref := yamlString(m["repository"])
+					if reg := yamlString(m["registry"]); reg != "" {
+						ref = reg + "/" + ref
+					}
+					if tag := yamlString(m["tag"]); tag != "" {
+						ref += ":" + tag
+					}
+					if digest := yamlString(m["digest"]); digest != "" {
+						ref += "@" + digest
+					}
+					add(ref, child)
+					continue
+				}
+			}
+			collectImages(node[k], child, add)
+		}
+	case []interface{}:
+		for _, item := range node {
+			collectImages(item, keyPath, add)
+		}
+	}
+}
+
+/************************************
+* Function Name: parseHelmValuesDeps
+* Purpose: Extract container images configured in a Helm values file, noting
+*          the values key each image was found under.
+* Parameters: path string
+* Output: []string (format: repo@tag (image, digest: x, key: a.b.image))
+*************************************/
+func parseHelmValuesDeps(path string) []string {
+	s, err := readFileContent(path)
+	if err != nil {
+		return nil
+	}
+	deps := map[string]struct{}{}
+	collectImages(parseYAML(s), "", func(ref, keyPath string) {
+		if dep := imageDep(ref, "key: "+keyPath); dep != "" {
+			deps[dep] = struct{}{}
+		}
+	})
+	return setToSortedSlice(deps)
+}
+
+/************************************
+* Function Name: parseKubernetesManifestDeps
+* Purpose: Extract container images from every document of a Kubernetes
+*          manifest, noting the workload kind and name.
+* Parameters: path string
+* Output: []string (format: repo@tag (image, digest: x, kind: Deployment/name))
+*************************************/
+func parseKubernetesManifestDeps(path string) []string {
+	s, err := readFileContent(path)
+	if err != nil {
+		return nil
+	}
+	deps := map[string]struct{}{}
+	for _, d := range parseYAMLDocuments(s) {
+		doc := yamlMap(d)
+		kind := yamlString(doc["kind"])
+		if name := yamlString(yamlMap(doc["metadata"])["name"]); name != "" {
+			kind += "/" + name
+		}
+		collectImages(doc["spec"], "", func(ref, _ string) {
+			if dep := imageDep(ref, "kind: "+kind); dep != "" {
+				deps[dep] = struct{}{}
+			}
+		})
+	}
+	return setToSortedSlice(deps)
+}
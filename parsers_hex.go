@@ -0,0 +1,318 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+/************************************
+* Function Name: splitTopLevel
+* Purpose: Split s on sep where it is not nested inside (), [] or {} and
+*          not inside a double-quoted string. Items are trimmed; empty ones dropped.
+* Parameters: s string, sep byte
+* Output: []string
+*************************************/
+func splitTopLevel(s string, sep byte) []string {
+	var items []string
+	depth, start, inString := 0, 0, false
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"' && (i == 0 || s[i-1] != '\\'):
+			inString = !inString
+		case inString:
+		case c == '(' || c == '[' || c == '{':
+			depth++
+		case c == ')' || c == ']' || c == '}':
+			depth--
+		case c == sep && depth == 0:
+			if item := strings.TrimSpace(s[start:i]); item != "" {
+				items = append(items, item)
+			}
+			start = i + 1
+		}
+	}
+	if item := strings.TrimSpace(s[start:]); item != "" {
+		items = append(items, item)
+	}
+	return items
+}
+
+/************************************
+* Function Name: balancedBlock
+* Purpose: Given the index of an opening bracket in s, return the text between it
+*          and its matching closing bracket.
+* Parameters: s string, open int
+* Output: string, int (index of the closing bracket, -1 if unbalanced)
+*************************************/
+func balancedBlock(s string, open int) (string, int) {
+	depth, inString := 0, false
+	for i := open; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"' && (i == 0 || s[i-1] != '\\'):
+			inString = !inString
+		case inString:
+		case c == '(' || c == '[' || c == '{':
+			depth++
+		case c == ')' || c == ']' || c == '}':
+			depth--
+			if depth == 0 {
+				return s[open+1 : i], i
+			}
+		}
+	}
+	return "", -1
+}
+
+/************************************
+* Function Name: parseMixExsDeps
+* Purpose: Extract dependency tuples from an Elixir mix.exs file. Handles version
+*          requirements, only: environments, git/github/path sources, hex: package
+*          renames and in_umbrella siblings.
+* Parameters: path string
+* Output: []string (format: name@requirement (only: dev/test, git: url, ...))
+*************************************/
+func parseMixExsDeps(path string) []string {
+	s, err := readFileContent(path)
+	if err != nil {
+		return nil
+	}
+	s = stripLineComments(s, "#")
+	deps := map[string]struct{}{}
+
+	// only look at the list returned by the deps function when there is one
+	if loc := regexp.MustCompile(`defp?\s+deps\b[^\[]*\[`).FindStringIndex(s); loc != nil {
+		if body, end := balancedBlock(s, loc[1]-1); end != -1 {
+			s = body
+		}
+	}
+
+	reTuple := regexp.MustCompile(`\{\s*:(\w+)\s*(,|\})`)
+	reVersion := regexp.MustCompile(`^"([^"]+)"$`)
+	reOpt := regexp.MustCompile(`^(\w+):\s*(.+)$`)
+	for _, loc := range reTuple.FindAllStringSubmatchIndex(s, -1) {
+		body, end := balancedBlock(s, loc[0])
+		if end == -1 {
+			continue
+		}
+		items := splitTopLevel(body, ',')
+		if len(items) == 0 {
+			continue
+		}
+		name := strings.TrimPrefix(items[0], ":")
+		version := ""
+		var notes []string
+		for _, item := range items[1:] {
+			if m := reVersion.FindStringSubmatch(item); len(m) > 1 {
+				version = m[1]
+				continue
+			}
+			// options may be written as a keyword list: [only: :dev, runtime: false]
+			opts := []string{item}
+			if strings.HasPrefix(item, "[") && strings.HasSuffix(item, "]") {
+				opts = splitTopLevel(item[1:len(item)-1], ',')
+			}
+			for _, opt := range opts {
+				om := reOpt.FindStringSubmatch(opt)
+				if len(om) < 3 {
+					continue
+				}
+				key, val := om[1], strings.TrimSpace(om[2])
+				val = strings.Trim(strings.TrimPrefix(val, ":"), `"`)
+				switch key {
+				case "only":
+					envs := strings.Trim(val, "[]")
+					var list []string
+					for _, e := range strings.Split(envs, ",") {
+						list = append(list, strings.Trim(strings.TrimSpace(e), `:"`))
+					}
+					notes = append(notes, "only: "+strings.Join(list, "/"))
+				case "git", "path", "tag", "branch", "ref":
+					notes = append(notes, key+": "+val)
+				case "github":
+					notes = append(notes, "git: https://github.com/"+val+".git")
+				case "hex":
+					notes = append(notes, "app: "+name)
+					name = val
+				case "in_umbrella":
+					if val == "true" {
+						notes = append(notes, "internal")
+					}
+				}
+			}
+		}
+		dep := name
+		if version != "" {
+			dep = fmt.Sprintf("%s@%s", name, version)
+		}
+		deps[annotateDep(dep, notes...)] = struct{}{}
+	}
+
+	return setToSortedSlice(deps)
+}
+
+/************************************
+* Function Name: parseMixLockDeps
+* Purpose: Extract locked packages from a mix.lock file. Hex entries carry the
+*          exact version and checksums; git entries carry the locked revision.
+* Parameters: path string
+* Output: []string (format: name@version (sha256: outer checksum) or name (git: url, revision: sha))
+*************************************/
+func parseMixLockDeps(path string) []string {
+	s, err := readFileContent(path)
+	if err != nil {
+		return nil
+	}
+	deps := map[string]struct{}{}
+
+	reHex := regexp.MustCompile(`"([^"]+)":\s*\{:hex,\s*:"?([\w]+)"?,\s*"([^"]+)",\s*"([0-9a-fA-F]+)"(.*)\}`)
+	reOuter := regexp.MustCompile(`,\s*"[^"]*",\s*"([0-9a-fA-F]{64})"\s*$`)
+	reGit := regexp.MustCompile(`"([^"]+)":\s*\{:git,\s*"([^"]+)",\s*"([0-9a-fA-F]+)"`)
+	for _, line := range strings.Split(s, "\n") {
+		if m := reHex.FindStringSubmatch(line); len(m) > 5 {
+			hash := "inner-checksum: " + strings.ToLower(m[4])
+			if om := reOuter.FindStringSubmatch(m[5]); len(om) > 1 {
+				hash = "sha256: " + strings.ToLower(om[1])
+			}
+			deps[annotateDep(fmt.Sprintf("%s@%s", m[2], m[3]), hash)] = struct{}{}
+			continue
+		}
+		if m := reGit.FindStringSubmatch(line); len(m) > 3 {
+			deps[annotateDep(m[1], "git: "+m[2], "revision: "+m[3])] = struct{}{}
+		}
+	}
+
+	return setToSortedSlice(deps)
+}
+
+/************************************
+* Function Name: parseRebarConfigDeps
+* Purpose: Extract dependencies from an Erlang rebar.config, including deps
+*          declared inside profiles (noted with the profile name).
+* Parameters: path string
+* Output: []string (format: name@version (profile: x, git: url, tag: x))
+*************************************/
+func parseRebarConfigDeps(path string) []string {
+	s, err := readFileContent(path)
+	if err != nil {
+		return nil
+	}
+	s = stripLineComments(s, "%")
+	deps := map[string]struct{}{}
+
+	// locate profile ranges so nested deps can be attributed to them
+	type span struct {
+		name       string
+		start, end int
+	}
+	var profiles []span
+	if loc := regexp.MustCompile(`\{\s*profiles\s*,\s*\[`).FindStringIndex(s); loc != nil {
+		body, _ := balancedBlock(s, loc[1]-1)
+		offset := loc[1]
+		for _, item := range splitTopLevel(body, ',') {
+			idx := strings.Index(body, item)
+			if m := regexp.MustCompile(`^\{\s*(\w+)\s*,`).FindStringSubmatch(item); len(m) > 1 {
+				profiles = append(profiles, span{m[1], offset + idx, offset + idx + len(item)})
+			}
+		}
+	}
+
+	reDeps := regexp.MustCompile(`\{\s*deps\s*,\s*\[`)
+	reVersion := regexp.MustCompile(`^"([^"]+)"$`)
+	reGit := regexp.MustCompile(`^\{\s*(git|hg)\s*,\s*"([^"]+)"(?:\s*,\s*\{\s*(tag|branch|ref)\s*,\s*"([^"]+)"\s*\})?`)
+	rePkg := regexp.MustCompile(`^\{\s*pkg\s*,\s*(\w+)\s*\}$`)
+	for _, loc := range reDeps.FindAllStringIndex(s, -1) {
+		body, end := balancedBlock(s, loc[1]-1)
+		if end == -1 {
+			continue
+		}
+		profile := ""
+		for _, p := range profiles {
+			if loc[0] >= p.start && loc[0] < p.end {
+				profile = "profile: " + p.name
+			}
+		}
+		for _, item := range splitTopLevel(body, ',') {
+			name := item
+			version := ""
+			notes := []string{profile}
+			if strings.HasPrefix(item, "{") {
+				inner, _ := balancedBlock(item, 0)
+				parts := splitTopLevel(inner, ',')
+				if len(parts) == 0 {
+					continue
+				}
+				name = parts[0]
+				for _, part := range parts[1:] {
+					if m := reVersion.FindStringSubmatch(part); len(m) > 1 {
+						version = m[1]
+					} else if m := reGit.FindStringSubmatch(part); len(m) > 2 {
+						notes = append(notes, m[1]+": "+m[2])
+						if m[3] != "" {
+							notes = append(notes, m[3]+": "+m[4])
+						}
+					} else if m := rePkg.FindStringSubmatch(part); len(m) > 1 {
+						notes = append(notes, "app: "+name)
+						name = m[1]
+					}
+				}
+			}
+			name = strings.Trim(name, `'"`)
+			if name == "" {
+				continue
+			}
+			dep := name
+			if version != "" {
+				dep = fmt.Sprintf("%s@%s", name, version)
+			}
+			deps[annotateDep(dep, notes...)] = struct{}{}
+		}
+	}
+
+	return setToSortedSlice(deps)
+}
+
+/************************************
+* Function Name: parseRebarLockDeps
+* Purpose: Extract locked packages from a rebar.lock. Level 0 entries are direct
+*          dependencies; sha256 checksums come from the pkg_hash_ext section.
+* Parameters: path string
+* Output: []string (format: name@version (direct|transitive, sha256: x))
+*************************************/
+func parseRebarLockDeps(path string) []string {
+	s, err := readFileContent(path)
+	if err != nil {
+		return nil
+	}
+	deps := map[string]struct{}{}
+
+	hashes := map[string]string{}
+	reHashes := regexp.MustCompile(`(?s)\{\s*pkg_hash_ext\s*,\s*\[(.*?)\]\s*\}`)
+	reHash := regexp.MustCompile(`\{\s*<<"([^"]+)">>\s*,\s*<<"([0-9A-Fa-f]+)">>\s*\}`)
+	if m := reHashes.FindStringSubmatch(s); len(m) > 1 {
+		for _, hm := range reHash.FindAllStringSubmatch(m[1], -1) {
+			hashes[hm[1]] = strings.ToLower(hm[2])
+		}
+	}
+
+	level := func(l string) string {
+		if l == "0" {
+			return "direct"
+		}
+		return "transitive"
+	}
+	rePkg := regexp.MustCompile(`\{\s*<<"([^"]+)">>\s*,\s*\{\s*pkg\s*,\s*<<"([^"]+)">>\s*,\s*<<"([^"]+)">>[^}]*\}\s*,\s*(\d+)\s*\}`)
+	for _, m := range rePkg.FindAllStringSubmatch(s, -1) {
+		hash := ""
+		if h, ok := hashes[m[1]]; ok {
+			hash = "sha256: " + h
+		}
+		deps[annotateDep(fmt.Sprintf("%s@%s", m[2], m[3]), level(m[4]), hash)] = struct{}{}
+	}
+	reGit := regexp.MustCompile(`\{\s*<<"([^"]+)">>\s*,\s*\{\s*git\s*,\s*"([^"]+)"\s*,\s*\{\s*\w+\s*,\s*"([^"]+)"\s*\}\s*\}\s*,\s*(\d+)\s*\}`)
+	for _, m := range reGit.FindAllStringSubmatch(s, -1) {
+		deps[annotateDep(m[1], level(m[4]), "git: "+m[2], "revision: "+m[3])] = struct{}{}
+	}
+
+	return setToSortedSlice(deps)
+}
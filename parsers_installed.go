@@ -0,0 +1,267 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime/debug"
+	"strings"
+)
+
+/************************************
+* Function Name: installedTreeKey
+* Purpose: Classify a directory that holds installed packages rather than
+*          declarations: node_modules, Python site-packages / dist-packages and
+*          the specifications directory of a RubyGems install.
+* Parameters: path string, name string (lower-cased directory name)
+* Output: string (detection key, empty for ordinary directories)
+*************************************/
+func installedTreeKey(path, name string) string {
+	switch name {
+	case "node_modules":
+		return "node/installed"
+	case "site-packages", "dist-packages":
+		return "python/installed"
+	case "specifications":
+		// <gem home>/specifications sits next to <gem home>/gems
+		if pathExists(filepath.Join(filepath.Dir(path), "gems")) {
+			return "ruby/installed"
+		}
+	}
+	return ""
+}
+
+/************************************
+* Function Name: parseNodeModulesDeps
+* Purpose: List the packages installed in a node_modules tree from their
+*          package.json files, keeping the real nesting path
+*          (node_modules/a/node_modules/b). Symlinked packages are not followed;
+*          pnpm's node_modules/.pnpm store holds the real copies.
+* Parameters: path string (node_modules directory)
+* Output: []string (format: name@version (evidence: installed, location: dir, license: x))
+*************************************/
+func parseNodeModulesDeps(path string) []string {
+	base := filepath.Dir(path)
+	deps := map[string]struct{}{}
+	walkSource(path, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() {
+			if info.Name() == ".bin" || info.Name() == ".cache" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.Name() != "package.json" {
+			return nil
+		}
+		// only package roots: node_modules/<name> or node_modules/@scope/<name>
+		dir := filepath.Dir(p)
+		parent := filepath.Dir(dir)
+		if strings.HasPrefix(filepath.Base(parent), "@") {
+			parent = filepath.Dir(parent)
+		}
+		if filepath.Base(parent) != "node_modules" {
+			return nil
+		}
+		s, err := readFileContent(p)
+		if err != nil {
+			return nil
+		}
+		var pkg struct {
+			Name    string          `json:"name"`
+			Version string          `json:"version"`
+			License json.RawMessage `json:"license"`
+		}
+		if json.Unmarshal([]byte(s), &pkg) != nil || pkg.Name == "" {
+			return nil
+		}
+		dep := pkg.Name
+		if pkg.Version != "" {
+			dep = fmt.Sprintf("%s@%s", pkg.Name, pkg.Version)
+		}
+		rel, err := filepath.Rel(base, dir)
+		if err != nil {
+			rel = dir
+		}
+		// "license": "MIT" or the legacy {"type": "MIT"}
+		var license string
+		var legacy struct {
+			Type string `json:"type"`
+		}
+		if json.Unmarshal(pkg.License, &license) != nil && json.Unmarshal(pkg.License, &legacy) == nil {
+			license = legacy.Type
+		}
+		deps[annotateDep(dep, "evidence: installed", "location: "+filepath.ToSlash(rel), licenseNote(license))] = struct{}{}
+		return nil
+	})
+	return setToSortedSlice(deps)
+}
+
+/************************************
+* Function Name: parseSitePackagesDeps
+* Purpose: List the distributions installed in a Python site-packages directory
+*          from *.dist-info/METADATA and *.egg-info (directory with PKG-INFO, or
+*          a single PKG-INFO style file). The installer recorded in
+*          dist-info/INSTALLER is kept as a note.
+* Parameters: path string (site-packages directory)
+* Output: []string (format: name@version (evidence: installed, installer: x, license: x))
+*************************************/
+func parseSitePackagesDeps(path string) []string {
+	entries, err := readSourceDir(path)
+	if err != nil {
+		return nil
+	}
+	deps := map[string]struct{}{}
+	for _, e := range entries {
+		name := e.Name()
+		var metadata, installer string
+		switch {
+		case strings.HasSuffix(name, ".dist-info") && e.IsDir():
+			metadata = filepath.Join(path, name, "METADATA")
+			if s, err := readFileContent(filepath.Join(path, name, "INSTALLER")); err == nil {
+				installer = strings.TrimSpace(s)
+			}
+		case strings.HasSuffix(name, ".egg-info") && e.IsDir():
+			metadata = filepath.Join(path, name, "PKG-INFO")
+		case strings.HasSuffix(name, ".egg-info"):
+			metadata = filepath.Join(path, name)
+		default:
+			continue
+		}
+		s, err := readFileContent(metadata)
+		if err != nil {
+			continue
+		}
+		// RFC 822 style headers; the first paragraph ends before the description
+		paras := parseDCFFields(s)
+		if len(paras) == 0 || paras[0]["Name"] == "" {
+			continue
+		}
+		dep := paras[0]["Name"]
+		if v := paras[0]["Version"]; v != "" {
+			dep = fmt.Sprintf("%s@%s", dep, v)
+		}
+		notes := []string{"evidence: installed"}
+		if installer != "" {
+			notes = append(notes, "installer: "+installer)
+		}
+		// License-Expression (core metadata 2.4), else a short License field
+		license := paras[0]["License-Expression"]
+		if l := paras[0]["License"]; license == "" && len(l) <= 64 && !strings.Contains(l, "\n") {
+			license = l
+		}
+		if license == "UNKNOWN" {
+			license = ""
+		}
+		notes = append(notes, licenseNote(license))
+		deps[annotateDep(dep, notes...)] = struct{}{}
+	}
+	return setToSortedSlice(deps)
+}
+
+var (
+	gemspecNameRe     = regexp.MustCompile(`(?m)^\s*\w+\.name\s*=\s*["']([^"']+)["']`)
+	gemspecVersionRe  = regexp.MustCompile(`(?m)^\s*\w+\.version\s*=\s*["']([^"']+)["']`)
+	gemspecPlatformRe = regexp.MustCompile(`(?m)^\s*\w+\.platform\s*=\s*["']([^"']+)["']`)
+	gemspecLicensesRe = regexp.MustCompile(`(?m)^\s*\w+\.licenses?\s*=\s*\[?([^\n\]]+)`)
+	quotedStringRe    = regexp.MustCompile(`["']([^"']+)["']`)
+)
+
+/************************************
+* Function Name: parseGemSpecificationsDeps
+* Purpose: List the gems installed in a RubyGems / Bundler gem home from the
+*          stub gemspecs in its specifications directory. Name and version come
+*          from the gemspec, falling back to the file name (name-version.gemspec).
+* Parameters: path string (specifications directory)
+* Output: []string (format: name@version (evidence: installed, platform: x, license: x))
+*************************************/
+func parseGemSpecificationsDeps(path string) []string {
+	files, err := globSource(filepath.Join(path, "*.gemspec"))
+	if err != nil {
+		return nil
+	}
+	deps := map[string]struct{}{}
+	for _, f := range files {
+		s, err := readFileContent(f)
+		if err != nil {
+			continue
+		}
+		var name, version, platform string
+		if m := gemspecNameRe.FindStringSubmatch(s); m != nil {
+			name = m[1]
+		}
+		if m := gemspecVersionRe.FindStringSubmatch(s); m != nil {
+			version = m[1]
+		}
+		if m := gemspecPlatformRe.FindStringSubmatch(s); m != nil && m[1] != "ruby" {
+			platform = m[1]
+		}
+		if name == "" {
+			base := strings.TrimSuffix(filepath.Base(f), ".gemspec")
+			idx := strings.LastIndex(base, "-")
+			if idx <= 0 {
+				continue
+			}
+			name, version = base[:idx], base[idx+1:]
+		}
+		dep := name
+		if version != "" {
+			dep = fmt.Sprintf("%s@%s", name, version)
+		}
+		notes := []string{"evidence: installed"}
+		if platform != "" {
+			notes = append(notes, "platform: "+platform)
+		}
+		if m := gemspecLicensesRe.FindStringSubmatch(s); m != nil {
+			var licenses []string
+			for _, q := range quotedStringRe.FindAllStringSubmatch(m[1], -1) {
+				licenses = append(licenses, q[1])
+			}
+			notes = append(notes, licenseNote(strings.Join(licenses, " OR ")))
+		}
+		deps[annotateDep(dep, notes...)] = struct{}{}
+	}
+	return setToSortedSlice(deps)
+}
+
+/************************************
+* Function Name: parseVendorModulesDeps
+* Purpose: List the modules copied into a Go vendor directory from
+*          vendor/modules.txt ("# path version [=> replacement [version]]").
+* Parameters: path string
+* Output: []string (format: module@version (evidence: installed, replace: x))
+*************************************/
+func parseVendorModulesDeps(path string) []string {
+	s, err := readFileContent(path)
+	if err != nil {
+		return nil
+	}
+	deps := map[string]struct{}{}
+	for _, line := range strings.Split(s, "\n") {
+		// "## explicit" markers and package lines do not start with "# "
+		if !strings.HasPrefix(line, "# ") {
+			continue
+		}
+		fields := strings.Fields(strings.TrimPrefix(line, "# "))
+		if len(fields) == 0 {
+			continue
+		}
+		m := &debug.Module{Path: fields[0]}
+		rest := fields[1:]
+		if len(rest) > 0 && rest[0] != "=>" {
+			m.Version, rest = rest[0], rest[1:]
+		}
+		if len(rest) >= 2 && rest[0] == "=>" {
+			m.Replace = &debug.Module{Path: rest[1]}
+			if len(rest) >= 3 {
+				m.Replace.Version = rest[2]
+			}
+		}
+		deps[goModuleDep(m, "evidence: installed")] = struct{}{}
+	}
+	return setToSortedSlice(deps)
+}
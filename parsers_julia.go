@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+/************************************
+* Function Name: tomlValue
+* Purpose: Return the value of a simple TOML "key = value" line with quotes
+*          removed. Arrays and inline tables are returned as written.
+* Parameters: line string
+* Output: key string, value string, ok bool
+*************************************/
+func tomlValue(line string) (string, string, bool) {
+	parts := strings.SplitN(line, "=", 2)
+	if len(parts) != 2 {
+		return "", "", false
+	}
+	key := strings.Trim(strings.TrimSpace(parts[0]), `"'`)
+	value := strings.TrimSpace(parts[1])
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') {
+		if end := strings.IndexByte(value[1:], value[0]); end != -1 {
+			value = value[1 : end+1]
+		}
+	} else if idx := strings.Index(value, " #"); idx != -1 {
+		value = strings.TrimSpace(value[:idx])
+	}
+	return key, value, key != ""
+}
+
+/************************************
+* Function Name: parseJuliaProjectDeps
+* Purpose: Extract [deps] and [extras] from a Julia Project.toml, attaching the
+*          package UUID and [compat] bound. Extras used by [targets] are noted
+*          with the target name.
+* Parameters: path string
+* Output: []string (format: name (uuid: x, compat: x[, extra | target: test]))
+*************************************/
+func parseJuliaProjectDeps(path string) []string {
+	s, err := readFileContent(path)
+	if err != nil {
+		return nil
+	}
+	deps := map[string]string{}
+	extras := map[string]string{}
+	compat := map[string]string{}
+	targets := map[string]string{}
+
+	reQuoted := regexp.MustCompile(`"([^"]+)"`)
+	section := ""
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			section = strings.Trim(line, "[] ")
+			continue
+		}
+		key, value, ok := tomlValue(line)
+		if !ok {
+			continue
+		}
+		switch section {
+		case "deps", "weakdeps":
+			deps[key] = value
+		case "extras":
+			extras[key] = value
+		case "compat":
+			compat[key] = value
+		case "targets":
+			for _, m := range reQuoted.FindAllStringSubmatch(value, -1) {
+				targets[m[1]] = key
+			}
+		}
+	}
+
+	out := map[string]struct{}{}
+	add := func(name, uuid string, notes ...string) {
+		all := []string{"uuid: " + uuid}
+		if c := strings.ReplaceAll(compat[name], " ", ""); c != "" {
+			// compat entries are caret bounds rather than versions
+			all = append(all, "compat: "+c)
+		}
+		out[annotateDep(name, append(all, notes...)...)] = struct{}{}
+	}
+	for name, uuid := range deps {
+		add(name, uuid)
+	}
+	for name, uuid := range extras {
+		if t, ok := targets[name]; ok {
+			add(name, uuid, "target: "+t)
+		} else {
+			add(name, uuid, "extra")
+		}
+	}
+	return setToSortedSlice(out)
+}
+
+/************************************
+* Function Name: parseJuliaManifestDeps
+* Purpose: Extract resolved packages from a Julia Manifest.toml. Supports the
+*          manifest_format 2.0 layout ([[deps.Name]]) and the older one ([[Name]]).
+*          Standard library entries have no version and are noted as stdlib.
+* Parameters: path string
+* Output: []string (format: name@version (uuid: x, git-tree-sha1: x))
+*************************************/
+func parseJuliaManifestDeps(path string) []string {
+	s, err := readFileContent(path)
+	if err != nil {
+		return nil
+	}
+	type entry struct{ name, uuid, version, tree, repo string }
+	var entries []*entry
+	var current *entry
+
+	reHeader := regexp.MustCompile(`^\[\[(?:deps\.)?"?([^\]"]+)"?\]\]$`)
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			current = nil
+			if m := reHeader.FindStringSubmatch(line); len(m) > 1 {
+				current = &entry{name: m[1]}
+				entries = append(entries, current)
+			}
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		key, value, ok := tomlValue(line)
+		if !ok {
+			continue
+		}
+		switch key {
+		case "uuid":
+			current.uuid = value
+		case "version":
+			current.version = value
+		case "git-tree-sha1":
+			current.tree = value
+		case "repo-url":
+			current.repo = value
+		}
+	}
+
+	deps := map[string]struct{}{}
+	for _, e := range entries {
+		dep := e.name
+		if e.version != "" {
+			dep = fmt.Sprintf("%s@%s", e.name, e.version)
+		}
+		notes := []string{}
+		if e.uuid != "" {
+			notes = append(notes, "uuid: "+e.uuid)
+		}
+		if e.repo != "" {
+			notes = append(notes, "git: "+e.repo)
+		}
+		if e.tree != "" {
+			notes = append(notes, "git-tree-sha1: "+e.tree)
+		} else if e.version == "" {
+			notes = append(notes, "stdlib")
+		}
+		deps[annotateDep(dep, notes...)] = struct{}{}
+	}
+	return setToSortedSlice(deps)
+}
@@ -0,0 +1,369 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+/************************************
+* Function Name: mavenCoordinate
+* Purpose: Normalise a colon-separated Maven coordinate (g:a, g:a:v,
+*          g:a:packaging:v or g:a:packaging:classifier:v) into group:artifact@version.
+* Parameters: coord string
+* Output: string (empty if coord has no group and artifact)
+*************************************/
+func mavenCoordinate(coord string) string {
+	parts := strings.Split(strings.TrimSpace(coord), ":")
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return ""
+	}
+	if len(parts) == 2 {
+		return fmt.Sprintf("%s:%s", parts[0], parts[1])
+	}
+	return fmt.Sprintf("%s:%s@%s", parts[0], parts[1], parts[len(parts)-1])
+}
+
+/************************************
+* Function Name: clojureLibToMaven
+* Purpose: Map a Clojure lib symbol to Maven group:artifact. An unqualified
+*          symbol such as ring means group and artifact are the same.
+* Parameters: lib string
+* Output: string
+*************************************/
+func clojureLibToMaven(lib string) string {
+	if parts := strings.SplitN(lib, "/", 2); len(parts) == 2 {
+		return fmt.Sprintf("%s:%s", parts[0], parts[1])
+	}
+	return fmt.Sprintf("%s:%s", lib, lib)
+}
+
+/************************************
+* Function Name: parseSbtDeps
+* Purpose: Extract libraryDependencies and addSbtPlugin modules from an sbt build
+*          file. %% and %%% append the Scala binary version derived from
+*          scalaVersion; versions given as vals are resolved.
+* Parameters: path string
+* Output: []string (format: group:artifact@version (scope: test | plugin))
+*************************************/
+func parseSbtDeps(path string) []string {
+	s, err := readFileContent(path)
+	if err != nil {
+		return nil
+	}
+	s = stripLineComments(s, "//")
+	deps := map[string]struct{}{}
+
+	vals := map[string]string{}
+	reVal := regexp.MustCompile(`(?m)^\s*(?:lazy\s+)?val\s+(\w+)\s*=\s*"([^"]+)"`)
+	for _, m := range reVal.FindAllStringSubmatch(s, -1) {
+		vals[m[1]] = m[2]
+	}
+
+	scalaSuffix := ""
+	reScala := regexp.MustCompile(`scalaVersion\s*:=\s*(?:"([^"]+)"|(\w+))`)
+	if m := reScala.FindStringSubmatch(s); len(m) > 2 {
+		v := m[1]
+		if v == "" {
+			v = vals[m[2]]
+		}
+		if parts := strings.Split(v, "."); len(parts) >= 2 {
+			if parts[0] == "3" {
+				scalaSuffix = "_3"
+			} else {
+				scalaSuffix = "_" + parts[0] + "." + parts[1]
+			}
+		}
+	}
+
+	reModule := regexp.MustCompile(`"([^"]+)"\s*(%%%|%%|%)\s*"([^"]+)"\s*%\s*(?:"([^"]+)"|([A-Za-z_][\w.]*))(?:\s*%\s*"?([A-Za-z][\w-]*)"?)?`)
+	rePlugin := regexp.MustCompile(`addSbtPlugin\(([^)]*)\)`)
+	pluginSpans := rePlugin.FindAllStringIndex(s, -1)
+	for _, loc := range reModule.FindAllStringSubmatchIndex(s, -1) {
+		m := make([]string, len(loc)/2)
+		for i := range m {
+			if loc[2*i] >= 0 {
+				m[i] = s[loc[2*i]:loc[2*i+1]]
+			}
+		}
+		group, op, artifact := m[1], m[2], m[3]
+		version := m[4]
+		if version == "" {
+			version = vals[m[5]]
+		}
+		var notes []string
+		if op != "%" {
+			if scalaSuffix != "" {
+				artifact += scalaSuffix
+			} else {
+				notes = append(notes, "cross-built")
+			}
+		}
+		if cfg := strings.ToLower(m[6]); cfg != "" && cfg != "compile" {
+			notes = append(notes, "scope: "+cfg)
+		}
+		for _, span := range pluginSpans {
+			if loc[0] >= span[0] && loc[0] < span[1] {
+				notes = append(notes, "plugin")
+			}
+		}
+		dep := fmt.Sprintf("%s:%s", group, artifact)
+		if version != "" {
+			dep = fmt.Sprintf("%s@%s", dep, version)
+		}
+		deps[annotateDep(dep, notes...)] = struct{}{}
+	}
+
+	return setToSortedSlice(deps)
+}
+
+// ednSpan is the bracketed body of a keyed form in an EDN/Clojure source.
+type ednSpan struct {
+	key        string
+	body       string
+	start, end int
+}
+
+/************************************
+* Function Name: ednSpans
+* Purpose: Find the body of every "key <open>" form in an EDN/Clojure source and
+*          return the text between the brackets with its start/end offsets.
+* Parameters: s string, re *regexp.Regexp (must end at the opening bracket)
+* Output: []ednSpan
+*************************************/
+func ednSpans(s string, re *regexp.Regexp) []ednSpan {
+	var spans []ednSpan
+	for _, loc := range re.FindAllStringSubmatchIndex(s, -1) {
+		body, end := balancedBlock(s, loc[1]-1)
+		if end == -1 {
+			continue
+		}
+		key := ""
+		if len(loc) > 3 && loc[2] >= 0 {
+			key = s[loc[2]:loc[3]]
+		}
+		spans = append(spans, ednSpan{key, body, loc[1], end})
+	}
+	return spans
+}
+
+/************************************
+* Function Name: enclosingEdnKey
+* Purpose: Return the name of the innermost keyword-keyed map (":name {") that
+*          contains offset within the given span, e.g. the profile or alias name.
+* Parameters: s string, outer ednSpan, offset int
+* Output: string
+*************************************/
+func enclosingEdnKey(s string, outer ednSpan, offset int) string {
+	name := ""
+	for _, inner := range ednSpans(s[:outer.end], regexp.MustCompile(`:([\w.\-]+)\s*\{`)) {
+		if inner.start > outer.start && inner.start < offset && offset <= inner.end {
+			name = inner.key
+		}
+	}
+	return name
+}
+
+/************************************
+* Function Name: parseProjectCljDeps
+* Purpose: Extract :dependencies (and :plugins) vectors from a Leiningen
+*          project.clj as Maven coordinates. Entries inside :profiles are noted
+*          with the profile name.
+* Parameters: path string
+* Output: []string (format: group:artifact@version (profile: dev | plugin))
+*************************************/
+func parseProjectCljDeps(path string) []string {
+	s, err := readFileContent(path)
+	if err != nil {
+		return nil
+	}
+	s = stripLineComments(s, ";")
+	deps := map[string]struct{}{}
+
+	profiles := ednSpans(s, regexp.MustCompile(`:profiles\s*\{`))
+	reEntry := regexp.MustCompile(`\[\s*([\w.\-]+(?:/[\w.\-]+)?)\s+"([^"]+)"`)
+	for _, span := range ednSpans(s, regexp.MustCompile(`:(dependencies|plugins)\s*\[`)) {
+		var notes []string
+		if span.key == "plugins" {
+			notes = append(notes, "plugin")
+		}
+		for _, p := range profiles {
+			if span.start > p.start && span.end <= p.end {
+				if name := enclosingEdnKey(s, p, span.start); name != "" {
+					notes = append(notes, "profile: "+name)
+				}
+			}
+		}
+		for _, m := range reEntry.FindAllStringSubmatch(span.body, -1) {
+			dep := fmt.Sprintf("%s@%s", clojureLibToMaven(m[1]), m[2])
+			deps[annotateDep(dep, notes...)] = struct{}{}
+		}
+	}
+
+	return setToSortedSlice(deps)
+}
+
+/************************************
+* Function Name: parseDepsEdnDeps
+* Purpose: Extract libs from a Clojure deps.edn: top-level :deps and the
+*          :extra-deps / :replace-deps / :override-deps of :aliases. Maven libs
+*          are mapped to group:artifact@version; git and local libs are noted.
+* Parameters: path string
+* Output: []string (format: group:artifact@version (alias: x) or lib (git: url, tag: x, revision: x))
+*************************************/
+func parseDepsEdnDeps(path string) []string {
+	s, err := readFileContent(path)
+	if err != nil {
+		return nil
+	}
+	s = stripLineComments(s, ";")
+	deps := map[string]struct{}{}
+
+	aliases := ednSpans(s, regexp.MustCompile(`:aliases\s*\{`))
+	reLib := regexp.MustCompile(`([\w.\-]+(?:/[\w.\-$]+)?)\s*\{([^{}]*)\}`)
+	reKey := regexp.MustCompile(`:([\w./\-]+)\s+"([^"]+)"`)
+	for _, span := range ednSpans(s, regexp.MustCompile(`:(deps|extra-deps|replace-deps|override-deps)\s*\{`)) {
+		var notes []string
+		for _, a := range aliases {
+			if span.start > a.start && span.end <= a.end {
+				if name := enclosingEdnKey(s, a, span.start); name != "" {
+					notes = append(notes, "alias: "+name)
+				}
+			}
+		}
+		for _, m := range reLib.FindAllStringSubmatch(span.body, -1) {
+			lib := m[1]
+			coord := map[string]string{}
+			for _, km := range reKey.FindAllStringSubmatch(m[2], -1) {
+				coord[km[1]] = km[2]
+			}
+			libNotes := append([]string{}, notes...)
+			switch {
+			case coord["mvn/version"] != "":
+				dep := fmt.Sprintf("%s@%s", clojureLibToMaven(lib), coord["mvn/version"])
+				deps[annotateDep(dep, libNotes...)] = struct{}{}
+			case coord["local/root"] != "":
+				libNotes = append(libNotes, "path: "+coord["local/root"])
+				deps[annotateDep(lib, libNotes...)] = struct{}{}
+			default:
+				url := coord["git/url"]
+				if url == "" && strings.HasPrefix(lib, "io.github.") {
+					url = "https://github.com/" + strings.TrimPrefix(lib, "io.github.") + ".git"
+				}
+				if url == "" && strings.HasPrefix(lib, "com.github.") {
+					url = "https://github.com/" + strings.TrimPrefix(lib, "com.github.") + ".git"
+				}
+				libNotes = append(libNotes, "git: "+url)
+				if tag := coord["git/tag"]; tag != "" {
+					libNotes = append(libNotes, "tag: "+tag)
+				}
+				sha := coord["git/sha"]
+				if sha == "" {
+					sha = coord["sha"]
+				}
+				if sha != "" {
+					libNotes = append(libNotes, "revision: "+sha)
+				}
+				deps[annotateDep(lib, libNotes...)] = struct{}{}
+			}
+		}
+	}
+
+	return setToSortedSlice(deps)
+}
+
+/************************************
+* Function Name: parseMavenInstallJSONDeps
+* Purpose: Extract pinned artifacts from a Bazel rules_jvm_external
+*          maven_install.json lockfile (both the dependency_tree and the newer
+*          artifacts layouts).
+* Parameters: path string
+* Output: []string (format: group:artifact@version (sha256: x))
+*************************************/
+func parseMavenInstallJSONDeps(path string) []string {
+	s, err := readFileContent(path)
+	if err != nil {
+		return nil
+	}
+	var data struct {
+		DependencyTree struct {
+			Dependencies []struct {
+				Coord  string `json:"coord"`
+				Sha256 string `json:"sha256"`
+			} `json:"dependencies"`
+		} `json:"dependency_tree"`
+		Artifacts map[string]struct {
+			Shasums map[string]string `json:"shasums"`
+			Version string            `json:"version"`
+		} `json:"artifacts"`
+	}
+	if err := json.Unmarshal([]byte(s), &data); err != nil {
+		return nil
+	}
+	deps := map[string]struct{}{}
+	for _, d := range data.DependencyTree.Dependencies {
+		if dep := mavenCoordinate(d.Coord); dep != "" {
+			hash := ""
+			if d.Sha256 != "" {
+				hash = "sha256: " + d.Sha256
+			}
+			deps[annotateDep(dep, hash)] = struct{}{}
+		}
+	}
+	for ga, a := range data.Artifacts {
+		dep := mavenCoordinate(ga + ":" + a.Version)
+		if dep == "" {
+			continue
+		}
+		hash := ""
+		if sum := a.Shasums["jar"]; sum != "" {
+			hash = "sha256: " + sum
+		}
+		deps[annotateDep(dep, hash)] = struct{}{}
+	}
+	return setToSortedSlice(deps)
+}
+
+/************************************
+* Function Name: parseModuleBazelDeps
+* Purpose: Extract Maven artifacts declared through rules_jvm_external in a
+*          MODULE.bazel file: maven.install(artifacts = [...]) and
+*          maven.artifact(group = ..., artifact = ..., version = ...).
+* Parameters: path string
+* Output: []string (format: group:artifact@version)
+*************************************/
+func parseModuleBazelDeps(path string) []string {
+	s, err := readFileContent(path)
+	if err != nil {
+		return nil
+	}
+	s = stripPythonComments(s)
+	deps := map[string]struct{}{}
+
+	reArtifacts := regexp.MustCompile(`artifacts\s*=\s*\[`)
+	reQuoted := regexp.MustCompile(`"([^"]+)"`)
+	for _, call := range append(findCallArgs(s, "maven.install("), findCallArgs(s, "maven_install(")...) {
+		for _, loc := range reArtifacts.FindAllStringIndex(call, -1) {
+			body, _ := balancedBlock(call, loc[1]-1)
+			for _, m := range reQuoted.FindAllStringSubmatch(body, -1) {
+				if dep := mavenCoordinate(m[1]); dep != "" {
+					deps[dep] = struct{}{}
+				}
+			}
+		}
+	}
+
+	reKwarg := regexp.MustCompile(`(\w+)\s*=\s*"([^"]*)"`)
+	for _, call := range findCallArgs(s, "maven.artifact(") {
+		kw := map[string]string{}
+		for _, m := range reKwarg.FindAllStringSubmatch(call, -1) {
+			kw[m[1]] = m[2]
+		}
+		if dep := mavenCoordinate(strings.Join([]string{kw["group"], kw["artifact"], kw["version"]}, ":")); dep != "" {
+			deps[strings.TrimSuffix(dep, "@")] = struct{}{}
+		}
+	}
+
+	return setToSortedSlice(deps)
+}
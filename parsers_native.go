@@ -0,0 +1,311 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+/************************************
+* Function Name: formatConanRef
+* Purpose: Turn a Conan reference (name/version[@user/channel][#revision]) into a
+*          dependency entry. Lockfile timestamps (%...) are dropped.
+* Parameters: ref string, notes ...string
+* Output: string (empty if ref is not a reference)
+*************************************/
+func formatConanRef(ref string, notes ...string) string {
+	ref = strings.TrimSpace(ref)
+	if idx := strings.Index(ref, "%"); idx != -1 {
+		ref = ref[:idx]
+	}
+	revision := ""
+	if idx := strings.Index(ref, "#"); idx != -1 {
+		revision = "revision: " + ref[idx+1:]
+		ref = ref[:idx]
+	}
+	channel := ""
+	if idx := strings.Index(ref, "@"); idx != -1 {
+		if uc := ref[idx+1:]; uc != "" && uc != "_/_" {
+			channel = "channel: " + uc
+		}
+		ref = ref[:idx]
+	}
+	parts := strings.SplitN(ref, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return ""
+	}
+	all := append([]string{channel, revision}, notes...)
+	return annotateDep(fmt.Sprintf("%s@%s", parts[0], parts[1]), all...)
+}
+
+/************************************
+* Function Name: parseConanfileTxtDeps
+* Purpose: Extract references from the [requires], [tool_requires],
+*          [build_requires] and [test_requires] sections of a conanfile.txt.
+* Parameters: path string
+* Output: []string (format: name@version (build, channel: x))
+*************************************/
+func parseConanfileTxtDeps(path string) []string {
+	s, err := readFileContent(path)
+	if err != nil {
+		return nil
+	}
+	deps := map[string]struct{}{}
+	section := ""
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.ToLower(strings.Trim(line, "[]"))
+			continue
+		}
+		note := ""
+		switch section {
+		case "requires":
+		case "tool_requires", "build_requires":
+			note = "build"
+		case "test_requires":
+			note = "test"
+		default:
+			continue
+		}
+		if dep := formatConanRef(line, note); dep != "" {
+			deps[dep] = struct{}{}
+		}
+	}
+	return setToSortedSlice(deps)
+}
+
+/************************************
+* Function Name: parseConanfilePyDeps
+* Purpose: Extract references from a conanfile.py: requires/tool_requires class
+*          attributes and self.requires(...) style calls in methods.
+* Parameters: path string
+* Output: []string (format: name@version (build, channel: x))
+*************************************/
+func parseConanfilePyDeps(path string) []string {
+	s, err := readFileContent(path)
+	if err != nil {
+		return nil
+	}
+	s = stripPythonComments(s)
+	deps := map[string]struct{}{}
+
+	noteFor := func(kind string) string {
+		switch kind {
+		case "tool_requires", "build_requires":
+			return "build"
+		case "test_requires":
+			return "test"
+		}
+		return ""
+	}
+	reQuoted := regexp.MustCompile(`["']([^"']+)["']`)
+
+	reCall := regexp.MustCompile(`self\.(requires|tool_requires|build_requires|test_requires)\(\s*["']([^"']+)["']`)
+	for _, m := range reCall.FindAllStringSubmatch(s, -1) {
+		if dep := formatConanRef(m[2], noteFor(m[1])); dep != "" {
+			deps[dep] = struct{}{}
+		}
+	}
+
+	reAttr := regexp.MustCompile(`(?m)^\s*(requires|tool_requires|build_requires|test_requires)\s*=\s*`)
+	for _, loc := range reAttr.FindAllStringSubmatchIndex(s, -1) {
+		kind := s[loc[2]:loc[3]]
+		value := s[loc[1]:]
+		if strings.HasPrefix(value, "(") || strings.HasPrefix(value, "[") {
+			value, _ = balancedBlock(value, 0)
+		} else if idx := strings.Index(value, "\n"); idx != -1 {
+			value = value[:idx]
+		}
+		for _, qm := range reQuoted.FindAllStringSubmatch(value, -1) {
+			if dep := formatConanRef(qm[1], noteFor(kind)); dep != "" {
+				deps[dep] = struct{}{}
+			}
+		}
+	}
+
+	return setToSortedSlice(deps)
+}
+
+/************************************
+* Function Name: parseConanLockDeps
+* Purpose: Extract locked references from a conan.lock file. Supports the Conan 2
+*          format (requires/build_requires lists) and the Conan 1 graph_lock nodes.
+* Parameters: path string
+* Output: []string (format: name@version (revision: x))
+*************************************/
+func parseConanLockDeps(path string) []string {
+	s, err := readFileContent(path)
+	if err != nil {
+		return nil
+	}
+	var data struct {
+		Requires       []string `json:"requires"`
+		BuildRequires  []string `json:"build_requires"`
+		PythonRequires []string `json:"python_requires"`
+		GraphLock      struct {
+			Nodes map[string]struct {
+				Ref string `json:"ref"`
+			} `json:"nodes"`
+		} `json:"graph_lock"`
+	}
+	if err := json.Unmarshal([]byte(s), &data); err != nil {
+		return nil
+	}
+	deps := map[string]struct{}{}
+	add := func(ref, note string) {
+		if dep := formatConanRef(ref, note); dep != "" {
+			deps[dep] = struct{}{}
+		}
+	}
+	for _, r := range data.Requires {
+		add(r, "")
+	}
+	for _, r := range data.BuildRequires {
+		add(r, "build")
+	}
+	for _, r := range data.PythonRequires {
+		add(r, "build")
+	}
+	for id, node := range data.GraphLock.Nodes {
+		if id != "0" { // node 0 is the consumer itself
+			add(node.Ref, "")
+		}
+	}
+	return setToSortedSlice(deps)
+}
+
+/************************************
+* Function Name: parseVcpkgJSONDeps
+* Purpose: Extract dependencies from a vcpkg.json manifest. Versions pinned in
+*          "overrides" win; otherwise the version>= constraint is kept and the
+*          builtin-baseline the port versions resolve against is noted.
+* Parameters: path string
+* Output: []string (format: name@version (override | baseline: sha, features: a/b, host))
+*************************************/
+func parseVcpkgJSONDeps(path string) []string {
+	s, err := readFileContent(path)
+	if err != nil {
+		return nil
+	}
+	var data struct {
+		Dependencies []json.RawMessage `json:"dependencies"`
+		Baseline     string            `json:"builtin-baseline"`
+		Overrides    []struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		} `json:"overrides"`
+	}
+	if err := json.Unmarshal([]byte(s), &data); err != nil {
+		return nil
+	}
+	overrides := map[string]string{}
+	for _, o := range data.Overrides {
+		overrides[o.Name] = o.Version
+	}
+
+	deps := map[string]struct{}{}
+	for _, raw := range data.Dependencies {
+		var dep struct {
+			Name       string   `json:"name"`
+			MinVersion string   `json:"version>="`
+			Features   []string `json:"features"`
+			Host       bool     `json:"host"`
+			Platform   string   `json:"platform"`
+		}
+		if err := json.Unmarshal(raw, &dep.Name); err != nil {
+			if err := json.Unmarshal(raw, &dep); err != nil {
+				continue
+			}
+		}
+		if dep.Name == "" {
+			continue
+		}
+		entry := dep.Name
+		var notes []string
+		if v, ok := overrides[dep.Name]; ok {
+			entry = fmt.Sprintf("%s@%s", dep.Name, v)
+			notes = append(notes, "override")
+		} else {
+			if dep.MinVersion != "" {
+				entry = fmt.Sprintf("%s@>=%s", dep.Name, dep.MinVersion)
+			}
+			if data.Baseline != "" {
+				notes = append(notes, "baseline: "+data.Baseline)
+			}
+		}
+		if len(dep.Features) > 0 {
+			notes = append(notes, "features: "+strings.Join(dep.Features, "/"))
+		}
+		if dep.Platform != "" {
+			notes = append(notes, "platform: "+dep.Platform)
+		}
+		if dep.Host {
+			notes = append(notes, "host")
+		}
+		deps[annotateDep(entry, notes...)] = struct{}{}
+	}
+	return setToSortedSlice(deps)
+}
+
+/************************************
+* Function Name: parseCMakeListsDeps
+* Purpose: Extract FetchContent_Declare and ExternalProject_Add declarations from a
+*          CMakeLists.txt, reporting GIT_TAG pins or URL downloads (with URL_HASH).
+* Parameters: path string
+* Output: []string (format: name@tag (git: url) or name (url: x, sha256: x))
+*************************************/
+func parseCMakeListsDeps(path string) []string {
+	s, err := readFileContent(path)
+	if err != nil {
+		return nil
+	}
+	s = stripLineComments(s, "#")
+	deps := map[string]struct{}{}
+
+	reCmd := regexp.MustCompile(`(?i)\b(?:FetchContent_Declare|ExternalProject_Add)\s*\(`)
+	reArg := regexp.MustCompile(`"[^"]*"|[^\s"]+`)
+	for _, loc := range reCmd.FindAllStringIndex(s, -1) {
+		body, end := balancedBlock(s, loc[1]-1)
+		if end == -1 {
+			continue
+		}
+		args := reArg.FindAllString(body, -1)
+		if len(args) == 0 {
+			continue
+		}
+		opts := map[string]string{}
+		for i := 1; i+1 < len(args); i++ {
+			switch key := strings.ToUpper(args[i]); key {
+			case "GIT_REPOSITORY", "GIT_TAG", "URL", "URL_HASH", "URL_MD5":
+				opts[key] = strings.Trim(args[i+1], `"`)
+				i++
+			}
+		}
+		name := strings.Trim(args[0], `"`)
+		entry := name
+		var notes []string
+		if repo := opts["GIT_REPOSITORY"]; repo != "" {
+			notes = append(notes, "git: "+repo)
+			if tag := opts["GIT_TAG"]; tag != "" {
+				entry = fmt.Sprintf("%s@%s", name, tag)
+			}
+		}
+		if u := opts["URL"]; u != "" {
+			notes = append(notes, "url: "+u)
+		}
+		if h := opts["URL_HASH"]; h != "" {
+			if parts := strings.SplitN(h, "=", 2); len(parts) == 2 {
+				notes = append(notes, strings.ToLower(parts[0])+": "+strings.ToLower(parts[1]))
+			}
+		} else if h := opts["URL_MD5"]; h != "" {
+			notes = append(notes, "md5: "+strings.ToLower(h))
+		}
+		deps[annotateDep(entry, notes...)] = struct{}{}
+	}
+	return setToSortedSlice(deps)
+}
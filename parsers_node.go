@@ -0,0 +1,354 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// nodeWorkspace describes the monorepo a package.json belongs to.
+type nodeWorkspace struct {
+	dir       string                       // directory holding the root package.json / pnpm-workspace.yaml
+	root      bool                         // dir declares workspaces (false: a lone package's overrides)
+	members   map[string]string            // package name -> member directory (relative to dir)
+	overrides map[string]string            // package name -> forced version
+	catalogs  map[string]map[string]string // pnpm catalog name -> package -> version
+}
+
+/************************************
+* Function Name: workspaceGlobRegexp
+* Purpose: Convert a workspace glob ("packages/*", "apps/**") into a regexp
+*          matching relative directory paths.
+* Parameters: pattern string
+* Output: *regexp.Regexp
+*************************************/
+func workspaceGlobRegexp(pattern string) *regexp.Regexp {
+	pattern = strings.TrimSuffix(strings.TrimPrefix(pattern, "./"), "/")
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case pattern[i] == '*':
+			b.WriteString("[^/]*")
+		default:
+			b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
+
+/************************************
+* Function Name: expandWorkspaceMembers
+* Purpose: Find the package.json files under dir matched by the workspace globs
+*          (negated "!pattern" entries exclude) and map package names to their
+*          directories. node_modules is never searched.
+* Parameters: dir string, patterns []string
+* Output: map[string]string (name -> relative directory)
+*************************************/
+func expandWorkspaceMembers(dir string, patterns []string) map[string]string {
+	var include, exclude []*regexp.Regexp
+	for _, p := range patterns {
+		if strings.HasPrefix(p, "!") {
+			exclude = append(exclude, workspaceGlobRegexp(p[1:]))
+		} else {
+			include = append(include, workspaceGlobRegexp(p))
+		}
+	}
+	members := map[string]string{}
+	if len(include) == 0 {
+		return members
+	}
+	walkSource(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() {
+			if info.Name() == "node_modules" || info.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.Name() != "package.json" {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, filepath.Dir(path))
+		if err != nil || rel == "." {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		matched := false
+		for _, re := range include {
+			matched = matched || re.MatchString(rel)
+		}
+		for _, re := range exclude {
+			matched = matched && !re.MatchString(rel)
+		}
+		if !matched {
+			return nil
+		}
+		s, err := readFileContent(path)
+		if err != nil {
+			return nil
+		}
+		var pkg struct {
+			Name string `json:"name"`
+		}
+		if json.Unmarshal([]byte(s), &pkg) == nil && pkg.Name != "" {
+			members[pkg.Name] = rel
+		}
+		return nil
+	})
+	return members
+}
+
+/************************************
+* Function Name: overridePackageName
+* Purpose: Reduce an overrides/resolutions key to the package it pins. Keys
+*          scoped below another package ("a/b", "a>b") only apply to
+*          transitive installs and yield "".
+* Parameters: key string
+* Output: string
+*************************************/
+func overridePackageName(key string) string {
+	key = strings.TrimPrefix(key, "**/")
+	if strings.Contains(key, ">") {
+		return ""
+	}
+	// drop a version selector: "foo@<2", "@scope/foo@1"
+	if idx := strings.LastIndex(key, "@"); idx > 0 {
+		key = key[:idx]
+	}
+	if strings.Count(key, "/") > 1 || (strings.Contains(key, "/") && !strings.HasPrefix(key, "@")) {
+		return ""
+	}
+	return key
+}
+
+/************************************
+* Function Name: loadNodeWorkspace
+* Purpose: Locate the workspace root above a package.json (a package.json with a
+*          "workspaces" field or a pnpm-workspace.yaml, searching up to root) and
+*          load its members, overrides/resolutions and pnpm catalogs. A
+*          package.json that is neither the root nor a member only gets its own
+*          overrides/resolutions.
+* Parameters: dir string, root string
+* Output: *nodeWorkspace (nil when the package has neither workspace nor overrides)
+*************************************/
+func loadNodeWorkspace(dir, root string) *nodeWorkspace {
+	root = filepath.Clean(root)
+	dir = filepath.Clean(dir)
+	for d := dir; ; d = filepath.Dir(d) {
+		if ws := readNodeWorkspace(d); ws != nil && ws.root {
+			rel, err := filepath.Rel(d, dir)
+			if err != nil {
+				break
+			}
+			rel = filepath.ToSlash(rel)
+			if rel == "." {
+				return ws
+			}
+			for _, member := range ws.members {
+				if member == rel {
+					return ws
+				}
+			}
+			break
+		}
+		if d == root || d == filepath.Dir(d) {
+			break
+		}
+	}
+	return readNodeWorkspace(dir)
+}
+
+// nodeWorkspaces caches the workspace definition of each directory for the
+// scan: every package.json of a monorepo looks up the same root, and
+// expanding its members walks the whole tree.
+var nodeWorkspaces = map[string]*nodeWorkspace{}
+
+/************************************
+* Function Name: readNodeWorkspace
+* Purpose: Return the (cached) workspace definition held in dir, if any.
+* Parameters: dir string
+* Output: *nodeWorkspace (nil when dir has neither workspaces nor overrides)
+*************************************/
+func readNodeWorkspace(dir string) *nodeWorkspace {
+	dir = filepath.Clean(dir)
+	if ws, ok := nodeWorkspaces[dir]; ok {
+		return ws
+	}
+	ws := parseNodeWorkspace(dir)
+	nodeWorkspaces[dir] = ws
+	return ws
+}
+
+/************************************
+* Function Name: parseNodeWorkspace
+* Purpose: Read the workspace definition held in dir, if any. Supports the npm /
+*          yarn "workspaces" field (array or {packages: [...]}), yarn
+*          "resolutions", npm "overrides", "pnpm.overrides" and
+*          pnpm-workspace.yaml (packages, catalog, catalogs, overrides).
+*          Overrides are kept for a single package too; members are only
+*          expanded for a real workspace root.
+* Parameters: dir string
+* Output: *nodeWorkspace (nil when dir has neither workspaces nor overrides)
+*************************************/
+func parseNodeWorkspace(dir string) *nodeWorkspace {
+	ws := &nodeWorkspace{
+		dir:       dir,
+		overrides: map[string]string{},
+		catalogs:  map[string]map[string]string{},
+	}
+	var patterns []string
+	isRoot := false
+
+	if s, err := readFileContent(filepath.Join(dir, "package.json")); err == nil {
+		var data struct {
+			Workspaces  json.RawMessage            `json:"workspaces"`
+			Overrides   map[string]json.RawMessage `json:"overrides"`
+			Resolutions map[string]string          `json:"resolutions"`
+			Pnpm        struct {
+				Overrides map[string]string `json:"overrides"`
+			} `json:"pnpm"`
+		}
+		if json.Unmarshal([]byte(s), &data) == nil {
+			if len(data.Workspaces) > 0 {
+				var list []string
+				var obj struct {
+					Packages []string `json:"packages"`
+				}
+				if json.Unmarshal(data.Workspaces, &list) == nil {
+					patterns, isRoot = list, true
+				} else if json.Unmarshal(data.Workspaces, &obj) == nil {
+					patterns, isRoot = obj.Packages, true
+				}
+			}
+			for key, raw := range data.Overrides {
+				var version string
+				var nested map[string]json.RawMessage
+				if json.Unmarshal(raw, &version) != nil {
+					// {"foo": {".": "1.0.0", "bar": "2.0.0"}} pins foo itself through "."
+					if json.Unmarshal(raw, &nested) != nil || json.Unmarshal(nested["."], &version) != nil {
+						continue
+					}
+				}
+				if name := overridePackageName(key); name != "" {
+					ws.overrides[name] = version
+				}
+			}
+			for _, m := range []map[string]string{data.Resolutions, data.Pnpm.Overrides} {
+				for key, version := range m {
+					if name := overridePackageName(key); name != "" {
+						ws.overrides[name] = version
+					}
+				}
+			}
+		}
+	}
+
+	if s, err := readFileContent(filepath.Join(dir, "pnpm-workspace.yaml")); err == nil {
+		isRoot = true
+		doc := yamlMap(parseYAML(s))
+		for _, p := range yamlList(doc["packages"]) {
+			patterns = append(patterns, yamlString(p))
+		}
+		for key, v := range yamlMap(doc["overrides"]) {
+			if name := overridePackageName(key); name != "" {
+				ws.overrides[name] = yamlString(v)
+			}
+		}
+		readCatalog := func(name string, m map[string]interface{}) {
+			if len(m) == 0 {
+				return
+			}
+			ws.catalogs[name] = map[string]string{}
+			for pkg, v := range m {
+				ws.catalogs[name][pkg] = yamlString(v)
+			}
+		}
+		readCatalog("default", yamlMap(doc["catalog"]))
+		for name, m := range yamlMap(doc["catalogs"]) {
+			readCatalog(name, yamlMap(m))
+		}
+	}
+
+	if !isRoot && len(ws.overrides) == 0 {
+		return nil
+	}
+	ws.root = isRoot
+	if isRoot {
+		ws.members = expandWorkspaceMembers(dir, patterns)
+	}
+	// "$foo" override values refer to the root's own dependency spec
+	for name, version := range ws.overrides {
+		if strings.HasPrefix(version, "$") {
+			if spec := rootDependencySpec(dir, version[1:]); spec != "" {
+				ws.overrides[name] = spec
+			} else {
+				delete(ws.overrides, name)
+			}
+		}
+	}
+	return ws
+}
+
+/************************************
+* Function Name: rootDependencySpec
+* Purpose: Look up the version spec of a direct dependency in dir/package.json.
+* Parameters: dir string, name string
+* Output: string
+*************************************/
+func rootDependencySpec(dir, name string) string {
+	s, err := readFileContent(filepath.Join(dir, "package.json"))
+	if err != nil {
+		return ""
+	}
+	var data struct {
+		Dependencies         map[string]interface{} `json:"dependencies"`
+		DevDependencies      map[string]interface{} `json:"devDependencies"`
+		PeerDependencies     map[string]interface{} `json:"peerDependencies"`
+		OptionalDependencies map[string]interface{} `json:"optionalDependencies"`
+	}
+	if err := json.Unmarshal([]byte(s), &data); err != nil {
+		return ""
+	}
+	for _, deps := range []map[string]interface{}{data.Dependencies, data.DevDependencies, data.PeerDependencies, data.OptionalDependencies} {
+		if v, ok := deps[name].(string); ok {
+			return v
+		}
+	}
+	return ""
+}
+
+/************************************
+* Function Name: parsePnpmWorkspaceDeps
+* Purpose: Report the members of a pnpm workspace (as internal packages) and
+*          the versions pinned by its catalogs.
+* Parameters: path string
+* Output: []string (format: name (internal, path: dir) or name@version (catalog: x))
+*************************************/
+func parsePnpmWorkspaceDeps(path string) []string {
+	ws := readNodeWorkspace(filepath.Dir(path))
+	if ws == nil {
+		return nil
+	}
+	deps := map[string]struct{}{}
+	for name, dir := range ws.members {
+		deps[annotateDep(name, "internal", "path: "+dir)] = struct{}{}
+	}
+	for catalog, pkgs := range ws.catalogs {
+		for name, version := range pkgs {
+			deps[annotateDep(name+"@"+version, "catalog: "+catalog)] = struct{}{}
+		}
+	}
+	return setToSortedSlice(deps)
+}
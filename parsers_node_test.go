@@ -0,0 +1,49 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParsePackageJSONOverrides(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		path  string
+		want  []string
+	}{
+		{"single package overrides", map[string]string{
+			"package.json": `{"dependencies":{"lodash":"^4.17.0"},"overrides":{"lodash":"4.17.21"}}`,
+		}, "package.json", []string{"lodash@4.17.21 (override)"}},
+		{"single package resolutions", map[string]string{
+			"package.json": `{"dependencies":{"minimist":"^1.2.0"},"resolutions":{"**/minimist":"1.2.8"}}`,
+		}, "package.json", []string{"minimist@1.2.8 (override)"}},
+		{"member uses root overrides", map[string]string{
+			"package.json":            `{"workspaces":["packages/*"],"overrides":{"lodash":"4.17.21"}}`,
+			"packages/a/package.json": `{"name":"a","dependencies":{"lodash":"^4.17.0"},"overrides":{"lodash":"4.0.0"}}`,
+		}, "packages/a/package.json", []string{"lodash@4.17.21 (override)"}},
+		{"non-member keeps own overrides", map[string]string{
+			"package.json":       `{"workspaces":["packages/*"],"overrides":{"lodash":"4.17.21"}}`,
+			"tools/package.json": `{"dependencies":{"lodash":"^4.17.0"},"overrides":{"lodash":"4.17.20"}}`,
+		}, "tools/package.json", []string{"lodash@4.17.20 (override)"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				path := filepath.Join(dir, name)
+				if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := parsePackageJSONDeps(filepath.Join(dir, tt.path), dir); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parsePackageJSONDeps() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,308 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+/************************************
+* Function Name: parseMSBuildProperties
+* Purpose: Extract properties declared in <PropertyGroup> blocks of an MSBuild file
+*          (csproj, fsproj, Directory.Build.props, ...).
+* Parameters: s string (file content)
+* Output: map[string]string
+*************************************/
+func parseMSBuildProperties(s string) map[string]string {
+	s = stripXMLComments(s)
+	props := map[string]string{}
+	reGroup := regexp.MustCompile(`(?s)<PropertyGroup[^>]*>(.*?)</PropertyGroup>`)
+	rePair := regexp.MustCompile(`<([\w.]+)(?:\s[^>]*)?>([^<]*)</([\w.]+)>`)
+	for _, gm := range reGroup.FindAllStringSubmatch(s, -1) {
+		for _, pm := range rePair.FindAllStringSubmatch(gm[1], -1) {
+			if pm[1] == pm[3] {
+				props[pm[1]] = strings.TrimSpace(pm[2])
+			}
+		}
+	}
+	return props
+}
+
+/************************************
+* Function Name: resolveMSBuildValue
+* Purpose: Resolve $(Property) references using a properties map; leaves unknown
+*          references intact.
+* Parameters: val string, props map[string]string
+* Output: string
+*************************************/
+func resolveMSBuildValue(val string, props map[string]string) string {
+	reVar := regexp.MustCompile(`\$\(([^)]+)\)`)
+	return reVar.ReplaceAllStringFunc(val, func(match string) string {
+		if v, ok := props[reVar.FindStringSubmatch(match)[1]]; ok {
+			return v
+		}
+		return match
+	})
+}
+
+/************************************
+* Function Name: findNearestFile
+* Purpose: Look for a file named name in dir and each parent directory, stopping
+*          at root. Mirrors how MSBuild locates Directory.*.props files.
+* Parameters: dir string, root string, name string
+* Output: string (empty when not found)
+*************************************/
+func findNearestFile(dir, root, name string) string {
+	root = filepath.Clean(root)
+	for d := filepath.Clean(dir); ; d = filepath.Dir(d) {
+		candidate := filepath.Join(d, name)
+		if pathExists(candidate) {
+			return candidate
+		}
+		if d == root || d == filepath.Dir(d) {
+			return ""
+		}
+	}
+}
+
+/************************************
+* Function Name: msbuildAttrs
+* Purpose: Parse the attributes of an XML start tag into a map.
+* Parameters: s string (attribute text)
+* Output: map[string]string
+*************************************/
+func msbuildAttrs(s string) map[string]string {
+	attrs := map[string]string{}
+	reAttr := regexp.MustCompile(`([\w.:]+)\s*=\s*"([^"]*)"`)
+	for _, m := range reAttr.FindAllStringSubmatch(s, -1) {
+		attrs[m[1]] = m[2]
+	}
+	return attrs
+}
+
+/************************************
+* Function Name: stripXMLComments
+* Purpose: Remove <!-- --> comments, so commented-out items are not read.
+* Parameters: s string
+* Output: string
+*************************************/
+func stripXMLComments(s string) string {
+	return regexp.MustCompile(`(?s)<!--.*?-->`).ReplaceAllString(s, "")
+}
+
+/************************************
+* Function Name: nugetProperties
+* Purpose: Collect the MSBuild properties visible to a project file: those from the
+*          nearest Directory.Build.props, overridden by the file's own properties.
+* Parameters: path string, root string, content string
+* Output: map[string]string
+*************************************/
+func nugetProperties(path, root, content string) map[string]string {
+	props := map[string]string{}
+	if !strings.EqualFold(filepath.Base(path), "Directory.Build.props") {
+		if dbp := findNearestFile(filepath.Dir(path), root, "Directory.Build.props"); dbp != "" {
+			if s, err := readFileContent(dbp); err == nil {
+				props = parseMSBuildProperties(s)
+			}
+		}
+	}
+	for k, v := range parseMSBuildProperties(content) {
+		props[k] = v
+	}
+	return props
+}
+
+/************************************
+* Function Name: parsePackageReferenceDeps
+* Purpose: Extract PackageReference items from an SDK-style project (csproj/fsproj)
+*          or Directory.Build.props. Versions may come from a Version attribute or
+*          child element, VersionOverride, or central package management
+*          (Directory.Packages.props); $(Property) references are resolved.
+*          Update items only change the version of a package included earlier
+*          in the file; they do not add one.
+* Parameters: path string, root string
+* Output: []string (format: name@version)
+*************************************/
+func parsePackageReferenceDeps(path, root string) []string {
+	s, err := readFileContent(path)
+	if err != nil {
+		return nil
+	}
+	s = stripXMLComments(s)
+	props := nugetProperties(path, root, s)
+
+	central := map[string]string{}
+	if cpm := findNearestFile(filepath.Dir(path), root, "Directory.Packages.props"); cpm != "" {
+		for _, dep := range parseDirectoryPackagesPropsDeps(cpm, root) {
+			if parts := strings.SplitN(dep, "@", 2); len(parts) == 2 {
+				central[strings.ToLower(parts[0])] = parts[1]
+			}
+		}
+	}
+
+	// included packages and their versions, by lower-case name
+	included := map[string]string{}
+	versions := map[string]string{}
+	reRef := regexp.MustCompile(`(?s)<PackageReference\s+([^>]*?)(?:/>|>(.*?)</PackageReference>)`)
+	reVersion := regexp.MustCompile(`<Version>\s*([^<]+?)\s*</Version>`)
+	for _, m := range reRef.FindAllStringSubmatch(s, -1) {
+		attrs := msbuildAttrs(m[1])
+		name := attrs["Include"]
+		update := name == ""
+		if update {
+			name = attrs["Update"]
+		}
+		if name == "" {
+			continue
+		}
+		key := strings.ToLower(name)
+		if _, ok := included[key]; update && !ok {
+			continue
+		}
+		version := attrs["VersionOverride"]
+		if version == "" {
+			version = attrs["Version"]
+		}
+		if version == "" {
+			if vm := reVersion.FindStringSubmatch(m[2]); len(vm) > 1 {
+				version = vm[1]
+			}
+		}
+		if update {
+			if version != "" {
+				versions[key] = resolveMSBuildValue(version, props)
+			}
+			continue
+		}
+		if version == "" {
+			version = central[key]
+		}
+		included[key] = name
+		versions[key] = resolveMSBuildValue(version, props)
+	}
+
+	deps := map[string]struct{}{}
+	for key, name := range included {
+		if version := versions[key]; version != "" {
+			deps[fmt.Sprintf("%s@%s", name, version)] = struct{}{}
+		} else {
+			deps[name] = struct{}{}
+		}
+	}
+	return setToSortedSlice(deps)
+}
+
+/************************************
+* Function Name: parseDirectoryPackagesPropsDeps
+* Purpose: Extract centrally managed versions (<PackageVersion>) from a
+*          Directory.Packages.props file.
+* Parameters: path string, root string
+* Output: []string (format: name@version)
+*************************************/
+func parseDirectoryPackagesPropsDeps(path, root string) []string {
+	s, err := readFileContent(path)
+	if err != nil {
+		return nil
+	}
+	s = stripXMLComments(s)
+	props := nugetProperties(path, root, s)
+
+	deps := map[string]struct{}{}
+	reItem := regexp.MustCompile(`<(?:PackageVersion|GlobalPackageReference)\s+([^>]*?)/?>`)
+	for _, m := range reItem.FindAllStringSubmatch(s, -1) {
+		attrs := msbuildAttrs(m[1])
+		name := attrs["Include"]
+		if name == "" {
+			continue
+		}
+		if version := resolveMSBuildValue(attrs["Version"], props); version != "" {
+			deps[fmt.Sprintf("%s@%s", name, version)] = struct{}{}
+		} else {
+			deps[name] = struct{}{}
+		}
+	}
+
+	return setToSortedSlice(deps)
+}
+
+/************************************
+* Function Name: parsePackagesConfigDeps
+* Purpose: Extract packages from a legacy NuGet packages.config file.
+* Parameters: path string
+* Output: []string (format: name@version, dev dependencies annotated)
+*************************************/
+func parsePackagesConfigDeps(path string) []string {
+	s, err := readFileContent(path)
+	if err != nil {
+		return nil
+	}
+	deps := map[string]struct{}{}
+	rePkg := regexp.MustCompile(`<package\s+([^>]*?)/?>`)
+	for _, m := range rePkg.FindAllStringSubmatch(stripXMLComments(s), -1) {
+		attrs := msbuildAttrs(m[1])
+		name := attrs["id"]
+		if name == "" {
+			continue
+		}
+		dep := name
+		if attrs["version"] != "" {
+			dep = fmt.Sprintf("%s@%s", name, attrs["version"])
+		}
+		dev := ""
+		if strings.EqualFold(attrs["developmentDependency"], "true") {
+			dev = "dev"
+		}
+		deps[annotateDep(dep, dev)] = struct{}{}
+	}
+	return setToSortedSlice(deps)
+}
+
+/************************************
+* Function Name: parseNuGetLockDeps
+* Purpose: Extract resolved packages from a NuGet packages.lock.json across all
+*          target frameworks. Content hashes are converted to hex sha512.
+* Parameters: path string
+* Output: []string (format: name@version (sha512: x[, transitive]))
+*************************************/
+func parseNuGetLockDeps(path string) []string {
+	s, err := readFileContent(path)
+	if err != nil {
+		return nil
+	}
+	var data struct {
+		Dependencies map[string]map[string]struct {
+			Type        string `json:"type"`
+			Resolved    string `json:"resolved"`
+			ContentHash string `json:"contentHash"`
+		} `json:"dependencies"`
+	}
+	if err := json.Unmarshal([]byte(s), &data); err != nil {
+		return nil
+	}
+	deps := map[string]struct{}{}
+	for _, pkgs := range data.Dependencies {
+		for name, p := range pkgs {
+			if strings.EqualFold(p.Type, "Project") {
+				continue // project-to-project references are not packages
+			}
+			dep := name
+			if p.Resolved != "" {
+				dep = fmt.Sprintf("%s@%s", name, p.Resolved)
+			}
+			hash := ""
+			if raw, err := base64.StdEncoding.DecodeString(p.ContentHash); err == nil && len(raw) > 0 {
+				hash = "sha512: " + hex.EncodeToString(raw)
+			}
+			transitive := ""
+			if strings.EqualFold(p.Type, "Transitive") {
+				transitive = "transitive"
+			}
+			deps[annotateDep(dep, hash, transitive)] = struct{}{}
+		}
+	}
+	return setToSortedSlice(deps)
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+/************************************
+* Function Name: osPackageDistro
+* Purpose: Read the distribution ID (debian, ubuntu, alpine, fedora, ...) from
+*          the os-release file of the root filesystem a package database
+*          belongs to. The root is the part of path before marker.
+* Parameters: path string, marker string (e.g. "var/lib/dpkg/")
+* Output: string (empty when unknown)
+*************************************/
+func osPackageDistro(path, marker string) string {
+	slashed := filepath.ToSlash(path)
+	idx := strings.LastIndex(slashed, marker)
+	if idx == -1 {
+		return ""
+	}
+	root := filepath.FromSlash(slashed[:idx])
+	for _, rel := range []string{"etc/os-release", "usr/lib/os-release"} {
+		s, err := readFileContent(filepath.Join(root, rel))
+		if err != nil {
+			continue
+		}
+		for _, line := range strings.Split(s, "\n") {
+			if strings.HasPrefix(line, "ID=") {
+				return strings.Trim(strings.TrimSpace(line[3:]), `"'`)
+			}
+		}
+	}
+	return ""
+}
+
+/************************************
+* Function Name: parseDpkgStatusDeps
+* Purpose: List the packages installed according to a dpkg status file
+*          (/var/lib/dpkg/status) or a distroless status.d entry. Packages that
+*          are only unpacked, half-configured or removed are skipped.
+* Parameters: path string
+* Output: []string (format: name@version (arch: x, origin: source, distro: x))
+*************************************/
+func parseDpkgStatusDeps(path string) []string {
+	s, err := readFileContent(path)
+	if err != nil {
+		return nil
+	}
+	distro := osPackageDistro(path, "var/lib/dpkg/")
+	deps := map[string]struct{}{}
+	for _, p := range parseDCFFields(s) {
+		if p["Package"] == "" {
+			continue
+		}
+		// "install ok installed"; status.d entries have no Status field
+		if status, ok := p["Status"]; ok && !strings.HasSuffix(status, " installed") {
+			continue
+		}
+		dep := p["Package"]
+		if p["Version"] != "" {
+			dep = fmt.Sprintf("%s@%s", dep, p["Version"])
+		}
+		// Source: name (version) when the source version differs
+		origin := strings.Fields(p["Source"] + " ")
+		notes := []string{"arch: " + p["Architecture"]}
+		if len(origin) > 0 && origin[0] != p["Package"] {
+			notes = append(notes, "origin: "+origin[0])
+		}
+		if distro != "" {
+			notes = append(notes, "distro: "+distro)
+		}
+		deps[annotateDep(dep, notes...)] = struct{}{}
+	}
+	return setToSortedSlice(deps)
+}
+
+/************************************
+* Function Name: parseApkInstalledDeps
+* Purpose: List the packages recorded in an Alpine apk database
+*          (/lib/apk/db/installed: one "X:value" block per package).
+* Parameters: path string
+* Output: []string (format: name@version (arch: x, origin: x, distro: x, license: x))
+*************************************/
+func parseApkInstalledDeps(path string) []string {
+	s, err := readFileContent(path)
+	if err != nil {
+		return nil
+	}
+	distro := osPackageDistro(path, "lib/apk/db/")
+	deps := map[string]struct{}{}
+	add := func(fields map[string]string) {
+		if fields["P"] == "" {
+			return
+		}
+		dep := fields["P"]
+		if fields["V"] != "" {
+			dep = fmt.Sprintf("%s@%s", dep, fields["V"])
+		}
+		notes := []string{"arch: " + fields["A"]}
+		if fields["o"] != "" && fields["o"] != fields["P"] {
+			notes = append(notes, "origin: "+fields["o"])
+		}
+		if distro != "" {
+			notes = append(notes, "distro: "+distro)
+		}
+		notes = append(notes, licenseNote(fields["L"]))
+		deps[annotateDep(dep, notes...)] = struct{}{}
+	}
+	fields := map[string]string{}
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if line == "" {
+			add(fields)
+			fields = map[string]string{}
+			continue
+		}
+		// file entries (F:, R:, ...) repeat; only the first value of a key is kept
+		if len(line) > 2 && line[1] == ':' {
+			if _, seen := fields[line[:1]]; !seen {
+				fields[line[:1]] = line[2:]
+			}
+		}
+	}
+	add(fields)
+	return setToSortedSlice(deps)
+}
+
+// rpm header tags read from the package database.
+const (
+	rpmTagName      = 1000
+	rpmTagVersion   = 1001
+	rpmTagRelease   = 1002
+	rpmTagEpoch     = 1003
+	rpmTagLicense   = 1014
+	rpmTagArch      = 1022
+	rpmTagSourceRPM = 1044
+)
+
+/************************************
+* Function Name: rpmHeaderTags
+* Purpose: Decode the string and int32 tags of an rpm header blob as stored in
+*          the rpm database (index count, data length, 16-byte index entries,
+*          then the data store).
+* Parameters: b []byte
+* Output: map[int]string
+*************************************/
+func rpmHeaderTags(b []byte) map[int]string {
+	tags := map[int]string{}
+	if len(b) < 8 {
+		return tags
+	}
+	il := int(binary.BigEndian.Uint32(b[0:4]))
+	dl := int(binary.BigEndian.Uint32(b[4:8]))
+	if il < 0 || dl < 0 || 8+il*16+dl > len(b) {
+		return tags
+	}
+	store := b[8+il*16 : 8+il*16+dl]
+	for i := 0; i < il; i++ {
+		e := b[8+i*16:]
+		tag := int(binary.BigEndian.Uint32(e[0:4]))
+		typ := binary.BigEndian.Uint32(e[4:8])
+		off := int(binary.BigEndian.Uint32(e[8:12]))
+		if off < 0 || off >= len(store) {
+			continue
+		}
+		switch typ {
+		case 4: // INT32
+			if off+4 <= len(store) {
+				tags[tag] = fmt.Sprint(binary.BigEndian.Uint32(store[off:]))
+			}
+		case 6, 8, 9: // STRING, STRING_ARRAY (first element), I18NSTRING
+			end := off
+			for end < len(store) && store[end] != 0 {
+				end++
+			}
+			tags[tag] = string(store[off:end])
+		}
+	}
+	return tags
+}
+
+/************************************
+* Function Name: parseRpmDBDeps
+* Purpose: List the packages of an rpm database in the SQLite format
+*          (rpmdb.sqlite, Packages table of header blobs). Pending changes in a
+*          -wal file are not read.
+* Parameters: path string
+* Output: []string (format: name@version-release (arch: x, epoch: n, origin: x.src.rpm, distro: x, license: x))
+*************************************/
+func parseRpmDBDeps(path string) []string {
+	b, err := readSourceFile(path)
+	if err != nil {
+		return nil
+	}
+	db, err := openSQLite(b)
+	if err != nil {
+		return nil
+	}
+	root, err := db.tableRoot("Packages")
+	if err != nil {
+		return nil
+	}
+	distro := osPackageDistro(path, "var/lib/rpm/")
+	if distro == "" {
+		distro = osPackageDistro(path, "usr/lib/sysimage/rpm/")
+	}
+	deps := map[string]struct{}{}
+	db.tableRows(root, func(cols []interface{}) {
+		// Packages(hnum INTEGER PRIMARY KEY, blob BLOB)
+		if len(cols) < 2 {
+			return
+		}
+		blob, ok := cols[1].([]byte)
+		if !ok {
+			return
+		}
+		tags := rpmHeaderTags(blob)
+		name := tags[rpmTagName]
+		// imported signing keys are stored as pseudo packages
+		if name == "" || name == "gpg-pubkey" {
+			return
+		}
+		dep := name
+		if tags[rpmTagVersion] != "" {
+			dep = fmt.Sprintf("%s@%s", name, tags[rpmTagVersion])
+			if tags[rpmTagRelease] != "" {
+				dep += "-" + tags[rpmTagRelease]
+			}
+		}
+		notes := []string{"arch: " + tags[rpmTagArch]}
+		if tags[rpmTagEpoch] != "" && tags[rpmTagEpoch] != "0" {
+			notes = append(notes, "epoch: "+tags[rpmTagEpoch])
+		}
+		if tags[rpmTagSourceRPM] != "" {
+			notes = append(notes, "origin: "+tags[rpmTagSourceRPM])
+		}
+		if distro != "" {
+			notes = append(notes, "distro: "+distro)
+		}
+		notes = append(notes, licenseNote(tags[rpmTagLicense]))
+		deps[annotateDep(dep, notes...)] = struct{}{}
+	})
+	return setToSortedSlice(deps)
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// hclBlock is a labelled block found in an HCL file.
+type hclBlock struct {
+	label string
+	body  string
+}
+
+/************************************
+* Function Name: stripHCLComments
+* Purpose: Remove #, // and block comments from HCL source, leaving strings intact.
+* Parameters: s string
+* Output: string
+*************************************/
+func stripHCLComments(s string) string {
+	reBlock := regexp.MustCompile(`(?s)/\*.*?\*/`)
+	return stripLineComments(stripLineComments(reBlock.ReplaceAllString(s, ""), "#"), "//")
+}
+
+/************************************
+* Function Name: hclBlocks
+* Purpose: Find blocks opened by re (which must match up to and including the
+*          "{") and return each block's first capture group and body.
+* Parameters: s string, re *regexp.Regexp
+* Output: []hclBlock
+*************************************/
+func hclBlocks(s string, re *regexp.Regexp) []hclBlock {
+	var blocks []hclBlock
+	for _, loc := range re.FindAllStringSubmatchIndex(s, -1) {
+		body, end := balancedBlock(s, loc[1]-1)
+		if end == -1 {
+			continue
+		}
+		label := ""
+		if len(loc) >= 4 && loc[2] != -1 {
+			label = s[loc[2]:loc[3]]
+		}
+		blocks = append(blocks, hclBlock{label: label, body: body})
+	}
+	return blocks
+}
+
+/************************************
+* Function Name: hclAttributes
+* Purpose: Collect the top-level attributes of an HCL block body. String values
+*          are unquoted; nested blocks and objects are skipped.
+* Parameters: body string
+* Output: map[string]string
+*************************************/
+func hclAttributes(body string) map[string]string {
+	attrs := map[string]string{}
+	reAttr := regexp.MustCompile(`^\s*([\w\-]+)\s*=\s*(.*)$`)
+	depth := 0
+	for _, line := range strings.Split(body, "\n") {
+		if depth == 0 {
+			if m := reAttr.FindStringSubmatch(line); m != nil {
+				attrs[m[1]] = strings.Trim(strings.TrimSpace(m[2]), `"`)
+			}
+		}
+		inString := false
+		for i := 0; i < len(line); i++ {
+			switch c := line[i]; {
+			case c == '"' && (i == 0 || line[i-1] != '\\'):
+				inString = !inString
+			case inString:
+			case c == '{' || c == '[':
+				depth++
+			case c == '}' || c == ']':
+				depth--
+			}
+		}
+	}
+	return attrs
+}
+
+/************************************
+* Function Name: terraformProviderAddress
+* Purpose: Expand a provider source ("hashicorp/aws", "aws") to its full
+*          registry address (registry.terraform.io/hashicorp/aws).
+* Parameters: source string
+* Output: string
+*************************************/
+func terraformProviderAddress(source string) string {
+	switch strings.Count(source, "/") {
+	case 0:
+		return "registry.terraform.io/hashicorp/" + source
+	case 1:
+		return "registry.terraform.io/" + source
+	}
+	return source
+}
+
+/************************************
+* Function Name: terraformModuleDep
+* Purpose: Classify a module source address and build its dependency entry.
+*          Registry modules keep their version constraint; git sources carry
+*          the ?ref= revision; local paths and archive URLs are noted.
+* Parameters: label string, source string, version string
+* Output: string (format: source@version (module: label, registry | git: url, ref: x | path: x | url: x))
+*************************************/
+func terraformModuleDep(label, source, version string) string {
+	version = strings.ReplaceAll(version, " ", "")
+	moduleNote := "module: " + label
+	switch {
+	case source == "":
+		return ""
+	case strings.HasPrefix(source, "./") || strings.HasPrefix(source, "../"):
+		return annotateDep(source, moduleNote, "path: "+source)
+	case strings.HasPrefix(source, "git::") || strings.HasPrefix(source, "git@") ||
+		strings.HasPrefix(source, "github.com/") || strings.HasPrefix(source, "bitbucket.org/"):
+		url := strings.TrimPrefix(source, "git::")
+		ref := ""
+		if idx := strings.Index(url, "?"); idx != -1 {
+			for _, q := range strings.Split(url[idx+1:], "&") {
+				if strings.HasPrefix(q, "ref=") {
+					ref = strings.TrimPrefix(q, "ref=")
+				}
+			}
+			url = url[:idx]
+		}
+		// git@host:org/repo.git -> host/org/repo.git, so that '@' stays the version separator
+		name := strings.TrimPrefix(strings.TrimPrefix(url, "https://"), "ssh://")
+		if strings.HasPrefix(name, "git@") {
+			name = strings.Replace(strings.TrimPrefix(name, "git@"), ":", "/", 1)
+		}
+		dep := name
+		notes := []string{moduleNote, "git: " + url}
+		if ref != "" {
+			dep = fmt.Sprintf("%s@%s", name, ref)
+			notes = append(notes, "ref: "+ref)
+		}
+		return annotateDep(dep, notes...)
+	case strings.Contains(source, "::") || strings.Contains(source, "://"):
+		// s3::, gcs::, http archives
+		return annotateDep(source, moduleNote, "url: "+source)
+	}
+	dep := source
+	if version != "" {
+		dep = fmt.Sprintf("%s@%s", source, version)
+	}
+	return annotateDep(dep, moduleNote, "registry")
+}
+
+/************************************
+* Function Name: parseTerraformDeps
+* Purpose: Extract providers from terraform { required_providers { ... } } (and
+*          legacy provider "x" { version = ... } blocks) and module sources from
+*          module "x" { ... } blocks of a .tf file.
+* Parameters: path string
+* Output: []string (format: registry.terraform.io/ns/name@constraint (provider, registry)
+*         or module entries from terraformModuleDep)
+*************************************/
+func parseTerraformDeps(path string) []string {
+	s, err := readFileContent(path)
+	if err != nil {
+		return nil
+	}
+	s = stripHCLComments(s)
+	deps := map[string]struct{}{}
+
+	addProvider := func(local, source, version string) {
+		if source == "" {
+			source = local
+		}
+		dep := terraformProviderAddress(source)
+		if version = strings.ReplaceAll(version, " ", ""); version != "" {
+			dep = fmt.Sprintf("%s@%s", dep, version)
+		}
+		deps[annotateDep(dep, "provider", "registry")] = struct{}{}
+	}
+	reEntry := regexp.MustCompile(`(?m)^\s*([\w\-]+)\s*=\s*(\{|"([^"]*)")`)
+	for _, rp := range hclBlocks(s, regexp.MustCompile(`required_providers\s*\{`)) {
+		consumed := 0
+		for _, loc := range reEntry.FindAllStringSubmatchIndex(rp.body, -1) {
+			if loc[0] < consumed {
+				continue // attribute inside a provider object already read
+			}
+			local := rp.body[loc[2]:loc[3]]
+			if loc[6] != -1 {
+				// legacy shorthand: aws = "~> 2.0"
+				addProvider(local, "", rp.body[loc[6]:loc[7]])
+				continue
+			}
+			obj, end := balancedBlock(rp.body, loc[5]-1)
+			consumed = end
+			// inline objects separate attributes with commas
+			attrs := hclAttributes(strings.Join(splitTopLevel(obj, ','), "\n"))
+			addProvider(local, attrs["source"], attrs["version"])
+		}
+	}
+	for _, p := range hclBlocks(s, regexp.MustCompile(`(?m)^\s*provider\s+"([^"]+)"\s*\{`)) {
+		if v := hclAttributes(p.body)["version"]; v != "" {
+			addProvider(p.label, "", v)
+		}
+	}
+	for _, m := range hclBlocks(s, regexp.MustCompile(`(?m)^\s*module\s+"([^"]+)"\s*\{`)) {
+		attrs := hclAttributes(m.body)
+		if dep := terraformModuleDep(m.label, attrs["source"], attrs["version"]); dep != "" {
+			deps[dep] = struct{}{}
+		}
+	}
+
+	return setToSortedSlice(deps)
+}
+
+/************************************
+* Function Name: parseTerraformLockDeps
+* Purpose: Extract locked providers from a .terraform.lock.hcl with their
+*          constraints and h1: package hashes.
+* Parameters: path string
+* Output: []string (format: address@version (provider, registry, constraints: x, h1: hash))
+*************************************/
+func parseTerraformLockDeps(path string) []string {
+	s, err := readFileContent(path)
+	if err != nil {
+		return nil
+	}
+	s = stripHCLComments(s)
+	deps := map[string]struct{}{}
+
+	reHash := regexp.MustCompile(`"h1:([^"]+)"`)
+	for _, p := range hclBlocks(s, regexp.MustCompile(`(?m)^\s*provider\s+"([^"]+)"\s*\{`)) {
+		attrs := hclAttributes(p.body)
+		dep := p.label
+		if v := attrs["version"]; v != "" {
+			dep = fmt.Sprintf("%s@%s", p.label, v)
+		}
+		notes := []string{"provider", "registry"}
+		if c := strings.ReplaceAll(attrs["constraints"], " ", ""); c != "" {
+			notes = append(notes, "constraints: "+c)
+		}
+		var hashes []string
+		for _, h := range reHash.FindAllStringSubmatch(p.body, -1) {
+			hashes = append(hashes, h[1])
+		}
+		if len(hashes) > 0 {
+			// h1 hashes are base64 and may contain '/', so they are space separated
+			notes = append(notes, "h1: "+strings.Join(hashes, " "))
+		}
+		deps[annotateDep(dep, notes...)] = struct{}{}
+	}
+
+	return setToSortedSlice(deps)
+}
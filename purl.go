@@ -0,0 +1,247 @@
+package main
+
+import (
+	"net/url"
+	"strings"
+)
+
+/************************************
+* Function Name: splitDep
+* Purpose: Split a dependency entry produced by the parsers back into its parts.
+*          Entries look like "name@version (note, key: value)"; both the version
+*          and the notes are optional. Scoped names such as "@types/node" keep
+*          their leading '@'.
+* Parameters: dep string
+* Output: name string, version string, notes []string
+*************************************/
+func splitDep(dep string) (string, string, []string) {
+	base := dep
+	var notes []string
+	if idx := strings.Index(dep, " ("); idx != -1 && strings.HasSuffix(dep, ")") {
+		base = dep[:idx]
+		notes = strings.Split(dep[idx+2:len(dep)-1], ", ")
+	}
+	if at := strings.LastIndex(base, "@"); at > 0 {
+		return base[:at], base[at+1:], notes
+	}
+	return base, "", notes
+}
+
+/************************************
+* Function Name: depNote
+* Purpose: Look up a "key: value" note on a dependency entry.
+* Parameters: notes []string, key string
+* Output: string, bool
+*************************************/
+func depNote(notes []string, key string) (string, bool) {
+	for _, n := range notes {
+		if n == key {
+			return "", true
+		}
+		if strings.HasPrefix(n, key+": ") {
+			return strings.TrimPrefix(n, key+": "), true
+		}
+	}
+	return "", false
+}
+
+/************************************
+* Function Name: isExactVersion
+* Purpose: Report whether a version string is a concrete version rather than a
+*          range or constraint (^1.2, ~> 3.0, >=1 <2, *, latest, ...).
+* Parameters: v string
+* Output: bool
+*************************************/
+func isExactVersion(v string) bool {
+	if v == "" || v == "latest" || v == "*" {
+		return false
+	}
+	return !strings.ContainsAny(v, " ^~<>=*,|[]()!")
+}
+
+// purlTypes maps ecosystem display names to package-url types.
+var purlTypes = map[string]string{
+	"Go":            "golang",
+	"Node":          "npm",
+	"Yarn":          "npm",
+	"Python":        "pypi",
+	"Maven":         "maven",
+	"Gradle":        "maven",
+	"Composer":      "composer",
+	"Ruby":          "gem",
+	"Rust":          "cargo",
+	"CocoaPods":     "cocoapods",
+	"NuGet":         "nuget",
+	"Dart":          "pub",
+	"Hex":           "hex",
+	"Conan":         "conan",
+	"CRAN":          "cran",
+	"Julia":         "julia",
+	"Hackage":       "hackage",
+	"Conda":         "conda",
+	"JSR":           "jsr",
+	"Docker":        "docker",
+	"System":        "generic",
+	"GitHubActions": "github",
+	"Generic":       "generic",
+	"Debian":        "deb",
+	"Alpine":        "apk",
+	"RPM":           "rpm",
+	"sbt":           "maven",
+	"Clojure":       "maven",
+	"Bazel":         "maven",
+}
+
+/************************************
+* Function Name: packageURL
+* Purpose: Build a package URL (purl) for a dependency entry of an ecosystem.
+*          Only registry packages get a purl: entries sourced from git, a local
+*          path or an SDK are skipped, and range constraints are left out of the
+*          version component.
+* Parameters: eco string (display name, e.g. "Hex"), dep string
+* Output: string (empty when no purl applies)
+*************************************/
+func packageURL(eco, dep string) string {
+	typ, ok := purlTypes[eco]
+	if !ok || strings.Contains(dep, " => ") {
+		return ""
+	}
+	name, version, notes := splitDep(dep)
+	for _, key := range []string{"git", "path", "sdk", "source", "internal", "stdlib"} {
+		if _, found := depNote(notes, key); found {
+			return ""
+		}
+	}
+
+	namespace := ""
+	subpath := ""
+	qualifiers := ""
+	if _, pip := depNote(notes, "pip"); pip && typ == "conda" {
+		typ = "pypi"
+	}
+	if eco == "System" {
+		// packages installed by Dockerfile RUN lines, keyed by installer
+		distro, _ := depNote(notes, "distro")
+		switch {
+		case hasNote(notes, "pip"):
+			typ = "pypi"
+		case hasNote(notes, "npm"):
+			typ = "npm"
+		case hasNote(notes, "apt"):
+			typ, namespace = "deb", distro
+			if namespace == "" {
+				namespace = "debian"
+			}
+		case hasNote(notes, "apk"):
+			typ, namespace = "apk", "alpine"
+		}
+	}
+	if hasNote(notes, "image") {
+		// container images referenced from workflows, charts, ...
+		typ = "docker"
+	}
+	switch typ {
+	case "github":
+		// owner/repo/path/to/action -> namespace owner, name repo, subpath
+		parts := strings.SplitN(name, "/", 3)
+		if len(parts) < 2 {
+			return ""
+		}
+		namespace, name = parts[0], parts[1]
+		if len(parts) == 3 {
+			subpath = parts[2]
+		}
+	case "docker":
+		if digest, _ := depNote(notes, "digest"); digest != "" {
+			version = digest
+		}
+		// registry hosts become the repository_url qualifier
+		if parts := strings.SplitN(name, "/", 2); len(parts) == 2 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+			if parts[0] != "docker.io" && parts[0] != "index.docker.io" {
+				qualifiers = "repository_url=" + parts[0]
+			}
+			name = parts[1]
+		}
+		name = strings.TrimPrefix(name, "library/")
+		if idx := strings.LastIndex(name, "/"); idx != -1 {
+			namespace, name = name[:idx], name[idx+1:]
+		}
+	case "maven":
+		parts := strings.SplitN(name, ":", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return ""
+		}
+		namespace, name = parts[0], parts[1]
+	case "golang", "npm", "composer", "jsr":
+		if idx := strings.LastIndex(name, "/"); idx != -1 {
+			namespace, name = name[:idx], name[idx+1:]
+		}
+	case "cocoapods":
+		if idx := strings.Index(name, "/"); idx != -1 {
+			name, subpath = name[:idx], name[idx+1:]
+		}
+	case "pypi":
+		name = strings.ReplaceAll(strings.ToLower(name), "_", "-")
+	case "hex", "pub", "cargo":
+		name = strings.ToLower(name)
+	case "cran":
+		if repo, _ := depNote(notes, "repository"); repo == "Bioconductor" {
+			typ = "bioconductor"
+		}
+	case "conda":
+		if ch, _ := depNote(notes, "channel"); ch != "" {
+			qualifiers = "channel=" + ch
+		}
+	case "deb", "apk", "rpm":
+		// OS packages from an installed package database
+		if namespace == "" {
+			namespace, _ = depNote(notes, "distro")
+		}
+		if namespace == "" && typ != "rpm" {
+			namespace = map[string]string{"deb": "debian", "apk": "alpine"}[typ]
+		}
+		var q []string
+		if arch, _ := depNote(notes, "arch"); arch != "" {
+			q = append(q, "arch="+arch)
+		}
+		if epoch, _ := depNote(notes, "epoch"); epoch != "" {
+			q = append(q, "epoch="+epoch)
+		}
+		qualifiers = strings.Join(q, "&")
+	case "julia":
+		if uuid, _ := depNote(notes, "uuid"); uuid != "" {
+			qualifiers = "uuid=" + uuid
+		}
+	}
+	if name == "" {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("pkg:" + typ + "/")
+	if namespace != "" {
+		for _, seg := range strings.Split(namespace, "/") {
+			b.WriteString(purlEscape(seg) + "/")
+		}
+	}
+	b.WriteString(purlEscape(name))
+	if isExactVersion(version) {
+		b.WriteString("@" + purlEscape(version))
+	}
+	if qualifiers != "" {
+		b.WriteString("?" + qualifiers)
+	}
+	if subpath != "" {
+		b.WriteString("#" + subpath)
+	}
+	return b.String()
+}
+
+func hasNote(notes []string, key string) bool {
+	_, found := depNote(notes, key)
+	return found
+}
+
+func purlEscape(s string) string {
+	return strings.ReplaceAll(url.PathEscape(s), "@", "%40")
+}
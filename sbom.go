@@ -0,0 +1,668 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// sbomPackage is a package read from a CycloneDX or SPDX document, before it
+// is turned into a dependency entry.
+type sbomPackage struct {
+	ref         string // bom-ref or SPDXID
+	name        string
+	version     string
+	purl        string
+	ecosystem   string   // sca-cli:ecosystem property of our own CycloneDX output
+	notes       []string // dev, optional, transitive, license: x, sha256: x, ...
+	requirement string   // sca-cli:requirement property (version range)
+}
+
+// cdxInComponent is a CycloneDX component as read from JSON or XML; nested
+// components are flattened by cyclonedxPackages.
+type cdxInComponent struct {
+	Type       string           `json:"type" xml:"type,attr"`
+	BOMRef     string           `json:"bom-ref" xml:"bom-ref,attr"`
+	Group      string           `json:"group" xml:"group"`
+	Name       string           `json:"name" xml:"name"`
+	Version    string           `json:"version" xml:"version"`
+	Scope      string           `json:"scope" xml:"scope"`
+	Hashes     []cdxHash        `json:"hashes" xml:"hashes>hash"`
+	Licenses   cdxLicenses      `json:"licenses" xml:"licenses"`
+	PURL       string           `json:"purl" xml:"purl"`
+	Properties []cdxProperty    `json:"properties" xml:"properties>property"`
+	Components []cdxInComponent `json:"components" xml:"components>component"`
+}
+
+type cdxInDependency struct {
+	Ref       string   `json:"ref" xml:"ref,attr"`
+	DependsOn []string `json:"dependsOn" xml:"-"`
+	Nested    []struct {
+		Ref string `xml:"ref,attr"`
+	} `json:"-" xml:"dependency"`
+}
+
+type cdxInBOM struct {
+	XMLName   xml.Name `json:"-" xml:"bom"` // other XML roots (pom.xml, ...) are rejected
+	BOMFormat string   `json:"bomFormat" xml:"-"`
+	Metadata  struct {
+		Component *cdxInComponent `json:"component" xml:"component"`
+	} `json:"metadata" xml:"metadata"`
+	Components   []cdxInComponent  `json:"components" xml:"components>component"`
+	Dependencies []cdxInDependency `json:"dependencies" xml:"dependencies>dependency"`
+}
+
+/************************************
+* Function Name: UnmarshalXML (cdxLicenses)
+* Purpose: Read the <license> and <expression> children of <licenses>.
+* Parameters: d *xml.Decoder, start xml.StartElement
+* Output: error
+*************************************/
+func (l *cdxLicenses) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var v struct {
+		Licenses    []cdxLicense `xml:"license"`
+		Expressions []string     `xml:"expression"`
+	}
+	if err := d.DecodeElement(&v, &start); err != nil {
+		return err
+	}
+	for i := range v.Licenses {
+		*l = append(*l, cdxLicenseChoice{License: &v.Licenses[i]})
+	}
+	for _, e := range v.Expressions {
+		*l = append(*l, cdxLicenseChoice{Expression: e})
+	}
+	return nil
+}
+
+// sbomHashNotes maps CycloneDX and SPDX hash algorithm names to note keys.
+var sbomHashNotes = map[string]string{
+	"MD5": "md5", "SHA-1": "sha1", "SHA1": "sha1", "SHA-256": "sha256", "SHA256": "sha256",
+	"SHA-384": "sha384", "SHA384": "sha384", "SHA-512": "sha512", "SHA512": "sha512",
+}
+
+/************************************
+* Function Name: hashNote
+* Purpose: Format a checksum from an SBOM as a dependency note
+*          ("sha256: <hex>"); unknown algorithms and non-hex values yield "".
+* Parameters: alg string, value string
+* Output: string
+*************************************/
+func hashNote(alg, value string) string {
+	key, ok := sbomHashNotes[strings.ToUpper(alg)]
+	if !ok || !hexDigestRe.MatchString(value) {
+		return ""
+	}
+	return key + ": " + strings.ToLower(value)
+}
+
+/************************************
+* Function Name: cyclonedxPackages
+* Purpose: Read the components of a CycloneDX BOM (JSON or XML). Components
+*          nested in other components are included; file components are not.
+*          When the dependency graph lists the metadata component, packages
+*          it does not depend on directly are noted transitive.
+* Parameters: b []byte, asXML bool
+* Output: []sbomPackage, string (name of the described component), error
+*************************************/
+func cyclonedxPackages(b []byte, asXML bool) ([]sbomPackage, string, error) {
+	var bom cdxInBOM
+	var err error
+	if asXML {
+		err = xml.Unmarshal(b, &bom)
+	} else {
+		err = json.Unmarshal(b, &bom)
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid CycloneDX document: %v", err)
+	}
+
+	name := ""
+	var direct map[string]bool
+	if root := bom.Metadata.Component; root != nil {
+		name = root.Name
+		for _, d := range bom.Dependencies {
+			if root.BOMRef == "" || d.Ref != root.BOMRef {
+				continue
+			}
+			direct = map[string]bool{}
+			for _, r := range d.DependsOn {
+				direct[r] = true
+			}
+			for _, r := range d.Nested {
+				direct[r.Ref] = true
+			}
+		}
+	}
+
+	var pkgs []sbomPackage
+	var walk func([]cdxInComponent)
+	walk = func(components []cdxInComponent) {
+		for _, c := range components {
+			walk(c.Components)
+			if c.Type == "file" || c.Name == "" {
+				continue
+			}
+			p := sbomPackage{ref: c.BOMRef, name: c.Name, version: c.Version, purl: c.PURL}
+			if c.Group != "" {
+				p.name = c.Group + ":" + c.Name
+			}
+			switch c.Scope {
+			case "excluded":
+				p.notes = append(p.notes, "dev")
+			case "optional":
+				p.notes = append(p.notes, "optional")
+			}
+			if direct != nil && !direct[c.BOMRef] {
+				p.notes = append(p.notes, "transitive")
+			}
+			var licenses []string
+			for _, l := range c.Licenses {
+				switch {
+				case l.Expression != "":
+					licenses = append(licenses, l.Expression)
+				case l.License != nil && l.License.ID != "":
+					licenses = append(licenses, l.License.ID)
+				case l.License != nil:
+					licenses = append(licenses, l.License.Name)
+				}
+			}
+			p.notes = append(p.notes, licenseNote(strings.Join(licenses, " OR ")))
+			for _, h := range c.Hashes {
+				p.notes = append(p.notes, hashNote(h.Alg, strings.TrimSpace(h.Content)))
+			}
+			for _, prop := range c.Properties {
+				switch prop.Name {
+				case "sca-cli:ecosystem":
+					p.ecosystem = prop.Value
+				case "sca-cli:requirement":
+					p.requirement = prop.Value
+				}
+			}
+			pkgs = append(pkgs, p)
+		}
+	}
+	walk(bom.Components)
+	return pkgs, name, nil
+}
+
+/************************************
+* Function Name: parseSPDXTagValue
+* Purpose: Read the document, package, extracted license and relationship
+*          fields of an SPDX tag-value document. Multi-line <text> values are
+*          joined; file and snippet sections are skipped.
+* Parameters: s string
+* Output: spdxDocument
+*************************************/
+func parseSPDXTagValue(s string) spdxDocument {
+	var doc spdxDocument
+	section := "document"
+	var pkg *spdxPackage
+	var lic *spdxExtractedLicense
+
+	lines := strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		kv := strings.SplitN(line, ":", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		tag, value := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+		if strings.HasPrefix(value, "<text>") {
+			value = strings.TrimPrefix(value, "<text>")
+			for !strings.Contains(value, "</text>") && i+1 < len(lines) {
+				i++
+				value += "\n" + lines[i]
+			}
+			value = strings.TrimSpace(strings.SplitN(value, "</text>", 2)[0])
+		}
+
+		switch tag {
+		case "DocumentName":
+			doc.Name = value
+		case "PackageName":
+			section = "package"
+			doc.Packages = append(doc.Packages, spdxPackage{Name: value})
+			pkg = &doc.Packages[len(doc.Packages)-1]
+		case "FileName", "SnippetSPDXID":
+			section = "file"
+		case "LicenseID":
+			section = "license"
+			doc.HasExtractedLicensingInfos = append(doc.HasExtractedLicensingInfos, spdxExtractedLicense{LicenseID: value})
+			lic = &doc.HasExtractedLicensingInfos[len(doc.HasExtractedLicensingInfos)-1]
+		case "Relationship":
+			if f := strings.Fields(value); len(f) == 3 {
+				doc.Relationships = append(doc.Relationships, spdxRelationship{f[0], f[1], f[2]})
+			}
+		case "SPDXID":
+			switch section {
+			case "document":
+				doc.SPDXID = value
+			case "package":
+				pkg.SPDXID = value
+			}
+		}
+
+		switch {
+		case section == "package":
+			switch tag {
+			case "PackageVersion":
+				pkg.VersionInfo = value
+			case "PackageLicenseDeclared":
+				pkg.LicenseDeclared = value
+			case "PackageLicenseConcluded":
+				pkg.LicenseConcluded = value
+			case "PackageChecksum":
+				if c := strings.SplitN(value, ":", 2); len(c) == 2 {
+					pkg.Checksums = append(pkg.Checksums, spdxChecksum{strings.TrimSpace(c[0]), strings.TrimSpace(c[1])})
+				}
+			case "ExternalRef":
+				if f := strings.Fields(value); len(f) == 3 {
+					pkg.ExternalRefs = append(pkg.ExternalRefs, spdxExternalRef{f[0], f[1], f[2]})
+				}
+			}
+		case section == "license":
+			switch tag {
+			case "LicenseName":
+				lic.Name = value
+			case "ExtractedText":
+				lic.ExtractedText = value
+			}
+		}
+	}
+	return doc
+}
+
+// spdxScopeNotes maps SPDX dependency relationships to dependency notes.
+var spdxScopeNotes = map[string]string{
+	"DEV_DEPENDENCY_OF":      "dev",
+	"TEST_DEPENDENCY_OF":     "test",
+	"BUILD_DEPENDENCY_OF":    "build",
+	"OPTIONAL_DEPENDENCY_OF": "optional",
+}
+
+/************************************
+* Function Name: spdxPackages
+* Purpose: Read the packages of an SPDX document other than the ones it
+*          describes. Dependency relationships give the scope (dev, test,
+*          build, optional); packages related to others but not to a
+*          described package are noted transitive.
+* Parameters: doc spdxDocument
+* Output: []sbomPackage, string (name of the described package)
+*************************************/
+func spdxPackages(doc spdxDocument) ([]sbomPackage, string) {
+	roots := map[string]bool{}
+	for _, id := range doc.DocumentDescribes {
+		roots[id] = true
+	}
+	for _, r := range doc.Relationships {
+		switch {
+		case r.SPDXElementID == doc.SPDXID && r.RelationshipType == "DESCRIBES":
+			roots[r.RelatedSPDXElement] = true
+		case r.RelatedSPDXElement == doc.SPDXID && r.RelationshipType == "DESCRIBED_BY":
+			roots[r.SPDXElementID] = true
+		}
+	}
+
+	// dependency edges: who depends on whom, read in both directions
+	scope := map[string]string{}
+	related := map[string]bool{}
+	direct := map[string]bool{}
+	for _, r := range doc.Relationships {
+		from, to := r.SPDXElementID, r.RelatedSPDXElement
+		switch {
+		case r.RelationshipType == "DEPENDS_ON" || r.RelationshipType == "CONTAINS":
+		case strings.HasSuffix(r.RelationshipType, "DEPENDENCY_OF"):
+			from, to = to, from
+			if note := spdxScopeNotes[r.RelationshipType]; note != "" {
+				scope[to] = note
+			}
+		default:
+			continue
+		}
+		related[to] = true
+		if roots[from] {
+			direct[to] = true
+		}
+	}
+
+	licenseNames := map[string]string{}
+	for _, l := range doc.HasExtractedLicensingInfos {
+		if l.Name != "" && l.Name != "NOASSERTION" {
+			licenseNames[l.LicenseID] = l.Name
+		}
+	}
+
+	name := doc.Name
+	var pkgs []sbomPackage
+	for _, sp := range doc.Packages {
+		if roots[sp.SPDXID] {
+			name = sp.Name
+			continue
+		}
+		p := sbomPackage{ref: sp.SPDXID, name: sp.Name, version: sp.VersionInfo}
+		for _, r := range sp.ExternalRefs {
+			if r.ReferenceType == "purl" {
+				p.purl = r.ReferenceLocator
+				break
+			}
+		}
+		p.notes = append(p.notes, scope[sp.SPDXID])
+		if related[sp.SPDXID] && !direct[sp.SPDXID] && len(roots) > 0 {
+			p.notes = append(p.notes, "transitive")
+		}
+		license := sp.LicenseDeclared
+		if license == "" || license == "NOASSERTION" || license == "NONE" {
+			license = sp.LicenseConcluded
+		}
+		if license == "NOASSERTION" || license == "NONE" {
+			license = ""
+		}
+		if n, ok := licenseNames[license]; ok {
+			license = n
+		}
+		p.notes = append(p.notes, licenseNote(license))
+		for _, c := range sp.Checksums {
+			p.notes = append(p.notes, hashNote(c.Algorithm, c.ChecksumValue))
+		}
+		pkgs = append(pkgs, p)
+	}
+	return pkgs, name
+}
+
+// purlEcosystems maps package-url types to ecosystem display names.
+var purlEcosystems = map[string]string{
+	"golang": "Go", "npm": "Node", "pypi": "Python", "maven": "Maven", "composer": "Composer",
+	"gem": "Ruby", "cargo": "Rust", "cocoapods": "CocoaPods", "nuget": "NuGet", "pub": "Dart",
+	"hex": "Hex", "conan": "Conan", "cran": "CRAN", "bioconductor": "CRAN", "julia": "Julia",
+	"hackage": "Hackage", "conda": "Conda", "jsr": "JSR", "docker": "Docker", "oci": "Docker",
+	"github": "GitHubActions", "generic": "Generic", "deb": "Debian", "apk": "Alpine", "rpm": "RPM",
+}
+
+/************************************
+* Function Name: purlDep
+* Purpose: Turn a package URL back into the ecosystem, name, version and notes
+*          the parsers would report for it (group:artifact for Maven,
+*          owner/repo/path for GitHub actions, distro and arch notes for OS
+*          packages, ...), so that packageURL rebuilds the same purl.
+* Parameters: purl string
+* Output: eco string, name string, version string, notes []string, ok bool
+*************************************/
+func purlDep(purl string) (string, string, string, []string, bool) {
+	if !strings.HasPrefix(purl, "pkg:") {
+		return "", "", "", nil, false
+	}
+	s := strings.TrimLeft(strings.TrimPrefix(purl, "pkg:"), "/")
+	subpath := ""
+	if idx := strings.Index(s, "#"); idx != -1 {
+		s, subpath = s[:idx], strings.Trim(s[idx+1:], "/")
+	}
+	qualifiers := url.Values{}
+	if idx := strings.Index(s, "?"); idx != -1 {
+		qualifiers, _ = url.ParseQuery(s[idx+1:])
+		s = s[:idx]
+	}
+	version := ""
+	if idx := strings.LastIndex(s, "@"); idx != -1 {
+		version, _ = url.PathUnescape(s[idx+1:])
+		s = s[:idx]
+	}
+	parts := strings.Split(s, "/")
+	if len(parts) < 2 {
+		return "", "", "", nil, false
+	}
+	typ := strings.ToLower(parts[0])
+	for i := range parts {
+		parts[i], _ = url.PathUnescape(parts[i])
+	}
+	namespace, name := strings.Join(parts[1:len(parts)-1], "/"), parts[len(parts)-1]
+
+	eco, ok := purlEcosystems[typ]
+	if !ok {
+		eco = "Generic"
+	}
+	var notes []string
+	full := name
+	if namespace != "" {
+		full = namespace + "/" + name
+	}
+	switch typ {
+	case "maven":
+		full = namespace + ":" + name
+	case "github":
+		if subpath != "" {
+			full += "/" + subpath
+		}
+	case "cocoapods":
+		if subpath != "" {
+			full = name + "/" + subpath
+		}
+	case "docker", "oci":
+		if host := qualifiers.Get("repository_url"); host != "" {
+			full = host + "/" + full
+		}
+		if strings.HasPrefix(version, "sha256:") {
+			notes = append(notes, "digest: "+version)
+			version = ""
+		}
+	case "deb", "apk", "rpm":
+		full = name
+		if arch := qualifiers.Get("arch"); arch != "" {
+			notes = append(notes, "arch: "+arch)
+		}
+		if epoch := qualifiers.Get("epoch"); epoch != "" {
+			notes = append(notes, "epoch: "+epoch)
+		}
+		if namespace != "" {
+			notes = append(notes, "distro: "+namespace)
+		}
+	case "bioconductor":
+		notes = append(notes, "repository: Bioconductor")
+	case "conda":
+		if ch := qualifiers.Get("channel"); ch != "" {
+			notes = append(notes, "channel: "+ch)
+		}
+	case "julia":
+		if uuid := qualifiers.Get("uuid"); uuid != "" {
+			notes = append(notes, "uuid: "+uuid)
+		}
+	}
+	return eco, full, version, notes, true
+}
+
+/************************************
+* Function Name: sbomDep
+* Purpose: Build the ecosystem and dependency entry for a package read from
+*          an SBOM. The purl decides the name and ecosystem; packages without
+*          one are reported under their own name in the Generic ecosystem
+*          (or the ecosystem recorded by sca-cli). Every entry is noted
+*          evidence: sbom.
+* Parameters: p sbomPackage
+* Output: eco string, dep string
+*************************************/
+func sbomDep(p sbomPackage) (string, string) {
+	eco, name, version, notes, ok := purlDep(p.purl)
+	if !ok {
+		eco, name = "Generic", p.name
+	}
+	// keep the finer ecosystem of our own output (Yarn, Gradle, ...) when it matches the purl
+	if p.ecosystem != "" && (!ok || purlTypes[p.ecosystem] == purlTypes[eco]) {
+		eco = p.ecosystem
+	}
+	if version == "" {
+		version = p.version
+	}
+	if version == "" {
+		version = p.requirement
+	}
+	dep := name
+	if version != "" {
+		dep = fmt.Sprintf("%s@%s", name, version)
+	}
+	digest, _ := depNote(notes, "digest")
+	for _, n := range p.notes {
+		// the image digest is also listed as the component hash
+		if digest == "" || n != strings.Replace(digest, ":", ": ", 1) {
+			notes = append(notes, n)
+		}
+	}
+	return eco, annotateDep(dep, append(notes, "evidence: sbom")...)
+}
+
+/************************************
+* Function Name: analyzeSBOM
+* Purpose: Build an Analysis from an existing SBOM: CycloneDX (JSON or XML)
+*          or SPDX (JSON or tag-value), recognised by content. Its packages
+*          are listed as dependencies of the SBOM file, so the result renders
+*          and merges like a scan.
+* Parameters: file string
+* Output: Analysis, error
+*************************************/
+func analyzeSBOM(file string) (Analysis, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return Analysis{}, err
+	}
+	sum := sha1.Sum(b)
+	b = bytes.TrimPrefix(b, []byte("\xef\xbb\xbf"))
+	trimmed := bytes.TrimSpace(b)
+
+	var pkgs []sbomPackage
+	var name string
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		var probe struct {
+			BOMFormat   string `json:"bomFormat"`
+			SPDXVersion string `json:"spdxVersion"`
+		}
+		if err := json.Unmarshal(trimmed, &probe); err != nil {
+			return Analysis{}, fmt.Errorf("%s: %v", file, err)
+		}
+		switch {
+		case probe.BOMFormat == "CycloneDX":
+			pkgs, name, err = cyclonedxPackages(trimmed, false)
+		case probe.SPDXVersion != "":
+			var doc spdxDocument
+			if err = json.Unmarshal(trimmed, &doc); err == nil {
+				pkgs, name = spdxPackages(doc)
+			}
+		default:
+			err = fmt.Errorf("not a CycloneDX or SPDX document")
+		}
+	case bytes.HasPrefix(trimmed, []byte("<")):
+		pkgs, name, err = cyclonedxPackages(trimmed, true)
+	case bytes.Contains(trimmed, []byte("SPDXVersion:")):
+		pkgs, name = spdxPackages(parseSPDXTagValue(string(trimmed)))
+	default:
+		err = fmt.Errorf("not a CycloneDX or SPDX document")
+	}
+	if err != nil {
+		return Analysis{}, fmt.Errorf("%s: %v", file, err)
+	}
+
+	var a Analysis
+	a.Repo = name
+	if a.Repo == "" {
+		a.Repo = filepath.Base(file)
+	}
+	// keyed by the path as given, so SBOMs with the same base name stay apart
+	key := filepath.ToSlash(filepath.Clean(file))
+	sets := map[string]map[string]struct{}{}
+	for _, p := range pkgs {
+		eco, dep := sbomDep(p)
+		if sets[eco] == nil {
+			sets[eco] = map[string]struct{}{}
+		}
+		sets[eco][dep] = struct{}{}
+	}
+	a.Dependencies = map[string]map[string][]string{}
+	for eco, set := range sets {
+		a.Dependencies[eco] = map[string][]string{key: setToSortedSlice(set)}
+		a.Type = append(a.Type, eco)
+	}
+	sort.Strings(a.Type)
+	a.Files = []string{key}
+	a.Purls = collectPurls(a.Dependencies)
+	a.fileSHA1 = map[string]string{key: hex.EncodeToString(sum[:])}
+	return a, nil
+}
+
+/************************************
+* Function Name: mergeAnalyses
+* Purpose: Combine analyses into one report: the first one keeps its name,
+*          commit and URL; types, files, vendored directories and the
+*          dependencies of every file and the lockfile edges are merged.
+* Parameters: a Analysis, others ...Analysis
+* Output: Analysis
+*************************************/
+func mergeAnalyses(a Analysis, others ...Analysis) Analysis {
+	if len(others) == 0 {
+		return a
+	}
+	if a.Dependencies == nil {
+		a.Dependencies = map[string]map[string][]string{}
+	}
+	if a.fileSHA1 == nil {
+		a.fileSHA1 = map[string]string{}
+	}
+	if a.dependsOn == nil {
+		a.dependsOn = map[string]map[string][]string{}
+	}
+	types := map[string]struct{}{}
+	files := map[string]struct{}{}
+	vendored := map[string]struct{}{}
+	add := func(set map[string]struct{}, list []string) {
+		for _, v := range list {
+			set[v] = struct{}{}
+		}
+	}
+	add(types, a.Type)
+	add(files, a.Files)
+	add(vendored, a.Vendored)
+	for _, o := range others {
+		add(types, o.Type)
+		add(files, o.Files)
+		add(vendored, o.Vendored)
+		for f, sum := range o.fileSHA1 {
+			a.fileSHA1[f] = sum
+		}
+		for eco, edges := range o.dependsOn {
+			if a.dependsOn[eco] == nil {
+				a.dependsOn[eco] = map[string][]string{}
+			}
+			for parent, children := range edges {
+				a.dependsOn[eco][parent] = append(a.dependsOn[eco][parent], children...)
+			}
+		}
+		for eco, perFile := range o.Dependencies {
+			if a.Dependencies[eco] == nil {
+				a.Dependencies[eco] = map[string][]string{}
+			}
+			for f, deps := range perFile {
+				set := map[string]struct{}{}
+				add(set, a.Dependencies[eco][f])
+				add(set, deps)
+				a.Dependencies[eco][f] = setToSortedSlice(set)
+			}
+		}
+	}
+	a.Type = setToSortedSlice(types)
+	a.Files = setToSortedSlice(files)
+	a.Vendored = nil
+	if len(vendored) > 0 {
+		a.Vendored = setToSortedSlice(vendored)
+	}
+	a.Purls = collectPurls(a.Dependencies)
+	return a
+}
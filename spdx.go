@@ -0,0 +1,413 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// spdxDocument is an SPDX 2.3 document; it is marshalled as JSON or written
+// in the tag-value format by spdxTagValue.
+type spdxDocument struct {
+	SPDXVersion                string                 `json:"spdxVersion"`
+	DataLicense                string                 `json:"dataLicense"`
+	SPDXID                     string                 `json:"SPDXID"`
+	Name                       string                 `json:"name"`
+	DocumentNamespace          string                 `json:"documentNamespace"`
+	CreationInfo               spdxCreationInfo       `json:"creationInfo"`
+	DocumentDescribes          []string               `json:"documentDescribes,omitempty"` // read from older documents
+	Packages                   []spdxPackage          `json:"packages"`
+	Files                      []spdxFile             `json:"files,omitempty"`
+	HasExtractedLicensingInfos []spdxExtractedLicense `json:"hasExtractedLicensingInfos,omitempty"`
+	Relationships              []spdxRelationship     `json:"relationships"`
+}
+
+type spdxCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type spdxPackage struct {
+	Name                  string            `json:"name"`
+	SPDXID                string            `json:"SPDXID"`
+	VersionInfo           string            `json:"versionInfo,omitempty"`
+	DownloadLocation      string            `json:"downloadLocation"`
+	FilesAnalyzed         bool              `json:"filesAnalyzed"`
+	Checksums             []spdxChecksum    `json:"checksums,omitempty"`
+	SourceInfo            string            `json:"sourceInfo,omitempty"`
+	LicenseConcluded      string            `json:"licenseConcluded"`
+	LicenseDeclared       string            `json:"licenseDeclared"`
+	CopyrightText         string            `json:"copyrightText"`
+	ExternalRefs          []spdxExternalRef `json:"externalRefs,omitempty"`
+	PrimaryPackagePurpose string            `json:"primaryPackagePurpose,omitempty"`
+}
+
+type spdxFile struct {
+	FileName         string         `json:"fileName"`
+	SPDXID           string         `json:"SPDXID"`
+	Checksums        []spdxChecksum `json:"checksums"`
+	LicenseConcluded string         `json:"licenseConcluded"`
+	CopyrightText    string         `json:"copyrightText"`
+}
+
+type spdxChecksum struct {
+	Algorithm     string `json:"algorithm"`
+	ChecksumValue string `json:"checksumValue"`
+}
+
+type spdxExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+type spdxExtractedLicense struct {
+	LicenseID     string `json:"licenseId"`
+	ExtractedText string `json:"extractedText"`
+	Name          string `json:"name"`
+}
+
+type spdxRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+var spdxIDInvalidRe = regexp.MustCompile(`[^A-Za-z0-9.-]+`)
+
+/************************************
+* Function Name: spdxID
+* Purpose: Build a unique SPDX identifier (letters, digits, '.' and '-') from
+*          a prefix and a free-form name; clashes get a numeric suffix.
+* Parameters: prefix string (e.g. "SPDXRef-Package-"), name string, used map[string]bool
+* Output: string
+*************************************/
+func spdxID(prefix, name string, used map[string]bool) string {
+	id := prefix + strings.Trim(spdxIDInvalidRe.ReplaceAllString(name, "-"), "-")
+	if used[id] {
+		for n := 2; ; n++ {
+			if candidate := fmt.Sprintf("%s-%d", id, n); !used[candidate] {
+				id = candidate
+				break
+			}
+		}
+	}
+	used[id] = true
+	return id
+}
+
+/************************************
+* Function Name: spdxLicense
+* Purpose: Convert CycloneDX license choices to an SPDX license expression.
+*          Licenses without an SPDX identifier become LicenseRef- ids, which
+*          are recorded in extracted with their original text; a name seen
+*          before keeps its id, and used keeps the ids of different names apart
+*          ("BSD License" and "BSD-License").
+* Parameters: licenses cdxLicenses, extracted map[string]spdxExtractedLicense,
+*          used map[string]bool (ids taken in the document)
+* Output: string (NOASSERTION when no license is known)
+*************************************/
+func spdxLicense(licenses cdxLicenses, extracted map[string]spdxExtractedLicense, used map[string]bool) string {
+	if len(licenses) == 0 {
+		return "NOASSERTION"
+	}
+	l := licenses[0]
+	switch {
+	case l.Expression != "":
+		return l.Expression
+	case l.License.ID != "":
+		return l.License.ID
+	}
+	for id, e := range extracted {
+		if e.ExtractedText == l.License.Name {
+			return id
+		}
+	}
+	id := spdxID("LicenseRef-", l.License.Name, used)
+	extracted[id] = spdxExtractedLicense{LicenseID: id, ExtractedText: l.License.Name, Name: l.License.Name}
+	return id
+}
+
+// spdxRelationshipRank orders dependency relationships so a package found in
+// several places keeps the strongest one.
+var spdxRelationshipRank = map[string]int{
+	"TEST_DEPENDENCY_OF": 0, "DEV_DEPENDENCY_OF": 1, "BUILD_DEPENDENCY_OF": 2,
+	"OPTIONAL_DEPENDENCY_OF": 3, "DEPENDS_ON": 4,
+}
+
+/************************************
+* Function Name: spdxRelationshipType
+* Purpose: Choose the SPDX relationship of a dependency from its notes: test
+*          (or a test scope), build, dev and optional / peer dependencies get
+*          the matching *_DEPENDENCY_OF type, the rest DEPENDS_ON.
+* Parameters: notes []string
+* Output: string
+*************************************/
+func spdxRelationshipType(notes []string) string {
+	scope, _ := depNote(notes, "scope")
+	switch {
+	case hasNote(notes, "test") || strings.Contains(strings.ToLower(scope), "test"):
+		return "TEST_DEPENDENCY_OF"
+	case hasNote(notes, "build"):
+		return "BUILD_DEPENDENCY_OF"
+	case hasNote(notes, "dev"):
+		return "DEV_DEPENDENCY_OF"
+	case hasNote(notes, "optional") || hasNote(notes, "peer"):
+		return "OPTIONAL_DEPENDENCY_OF"
+	}
+	return "DEPENDS_ON"
+}
+
+/************************************
+* Function Name: spdxNamespace
+* Purpose: Derive the document namespace from the repository URL (or name)
+*          and the scanned commit, e.g.
+*          https://spdx.org/spdxdocs/github.com/acme/app-<commit>. Scans
+*          without a commit get a random UUID instead.
+* Parameters: a Analysis
+* Output: string
+*************************************/
+func spdxNamespace(a Analysis) string {
+	base := a.Repo
+	if a.VCS != "" {
+		base = a.VCS
+	}
+	base = strings.TrimSuffix(strings.TrimSuffix(base, "/"), ".git")
+	if idx := strings.Index(base, "://"); idx != -1 {
+		base = base[idx+3:]
+	}
+	// user@host:owner/repo (scp-like git URLs) and credentials
+	if at := strings.Index(base, "@"); at != -1 && (strings.Index(base, "/") == -1 || at < strings.Index(base, "/")) {
+		base = strings.Replace(base[at+1:], ":", "/", 1)
+	}
+	var segments []string
+	for _, seg := range strings.Split(base, "/") {
+		if seg != "" {
+			segments = append(segments, url.PathEscape(seg))
+		}
+	}
+	id := a.Commit
+	if id == "" {
+		id = newUUID()
+	}
+	return "https://spdx.org/spdxdocs/" + strings.Join(segments, "/") + "-" + id
+}
+
+/************************************
+* Function Name: spdxDocumentFor
+* Purpose: Build an SPDX 2.3 document from an Analysis. The scanned
+*          repository is the described package; it CONTAINS one file entry
+*          per scanned manifest (with its SHA-1) and is related to every package
+*          found, with the type chosen by spdxRelationshipType. Packages are
+*          the de-duplicated components of the CycloneDX output.
+* Parameters: a Analysis
+* Output: spdxDocument
+*************************************/
+func spdxDocumentFor(a Analysis) spdxDocument {
+	doc := spdxDocument{
+		SPDXVersion:       "SPDX-2.3",
+		DataLicense:       "CC0-1.0",
+		SPDXID:            "SPDXRef-DOCUMENT",
+		Name:              a.Repo,
+		DocumentNamespace: spdxNamespace(a),
+		CreationInfo: spdxCreationInfo{
+			Created:  time.Now().UTC().Format(time.RFC3339),
+			Creators: []string{"Tool: sca-cli"},
+		},
+	}
+	used := map[string]bool{"SPDXRef-DOCUMENT": true}
+
+	root := spdxPackage{
+		Name:                  a.Repo,
+		SPDXID:                spdxID("SPDXRef-", "Repository", used),
+		VersionInfo:           a.Commit,
+		DownloadLocation:      "NOASSERTION",
+		LicenseConcluded:      "NOASSERTION",
+		LicenseDeclared:       "NOASSERTION",
+		CopyrightText:         "NOASSERTION",
+		PrimaryPackagePurpose: "APPLICATION",
+	}
+	if strings.Contains(a.VCS, "://") {
+		root.DownloadLocation = "git+" + a.VCS
+		if a.Commit != "" {
+			root.DownloadLocation += "@" + a.Commit
+		}
+	}
+	doc.Relationships = append(doc.Relationships, spdxRelationship{doc.SPDXID, "DESCRIBES", root.SPDXID})
+
+	// only the manifests are listed, not every file of the repository, so
+	// FilesAnalyzed stays false and no verification code is given
+	for _, f := range a.Files {
+		sum, ok := a.fileSHA1[f]
+		if !ok {
+			continue
+		}
+		file := spdxFile{
+			FileName:         "./" + strings.TrimLeft(strings.TrimPrefix(strings.ReplaceAll(f, "\\", "/"), "./"), "/"),
+			SPDXID:           spdxID("SPDXRef-File-", f, used),
+			Checksums:        []spdxChecksum{{Algorithm: "SHA1", ChecksumValue: sum}},
+			LicenseConcluded: "NOASSERTION",
+			CopyrightText:    "NOASSERTION",
+		}
+		doc.Files = append(doc.Files, file)
+		doc.Relationships = append(doc.Relationships, spdxRelationship{root.SPDXID, "CONTAINS", file.SPDXID})
+	}
+	doc.Packages = append(doc.Packages, root)
+
+	// relationship per package, from the notes of every entry that names it
+	relationships := map[string]string{}
+	for eco, perFile := range a.Dependencies {
+		for _, deps := range perFile {
+			for _, dep := range deps {
+				name, version, notes := splitDep(dep)
+				if name == "" {
+					continue
+				}
+				ref := cyclonedxRef(eco, name, version, packageURL(eco, dep))
+				rel := spdxRelationshipType(notes)
+				if old, ok := relationships[ref]; !ok || spdxRelationshipRank[rel] > spdxRelationshipRank[old] {
+					relationships[ref] = rel
+				}
+			}
+		}
+	}
+
+	extracted := map[string]spdxExtractedLicense{}
+	components, _ := cyclonedxComponents(a)
+	for _, c := range components {
+		p := spdxPackage{
+			Name:                  c.Name,
+			SPDXID:                spdxID("SPDXRef-Package-", strings.TrimPrefix(c.BOMRef, "pkg:"), used),
+			VersionInfo:           c.Version,
+			DownloadLocation:      "NOASSERTION",
+			LicenseConcluded:      "NOASSERTION",
+			LicenseDeclared:       spdxLicense(c.Licenses, extracted, used),
+			CopyrightText:         "NOASSERTION",
+			PrimaryPackagePurpose: strings.ToUpper(c.Type),
+		}
+		for _, h := range c.Hashes {
+			p.Checksums = append(p.Checksums, spdxChecksum{Algorithm: strings.ReplaceAll(h.Alg, "-", ""), ChecksumValue: h.Content})
+		}
+		var files []string
+		for _, prop := range c.Properties {
+			if prop.Name == "sca-cli:file" {
+				files = append(files, prop.Value)
+			}
+		}
+		if len(files) > 0 {
+			p.SourceInfo = "declared in " + strings.Join(files, ", ")
+		}
+		if c.PURL != "" {
+			p.ExternalRefs = []spdxExternalRef{{ReferenceCategory: "PACKAGE-MANAGER", ReferenceType: "purl", ReferenceLocator: c.PURL}}
+		}
+		doc.Packages = append(doc.Packages, p)
+
+		if rel := relationships[c.BOMRef]; rel != "" && rel != "DEPENDS_ON" {
+			doc.Relationships = append(doc.Relationships, spdxRelationship{p.SPDXID, rel, root.SPDXID})
+		} else {
+			doc.Relationships = append(doc.Relationships, spdxRelationship{root.SPDXID, "DEPENDS_ON", p.SPDXID})
+		}
+	}
+	ids := make([]string, 0, len(extracted))
+	for id := range extracted {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		doc.HasExtractedLicensingInfos = append(doc.HasExtractedLicensingInfos, extracted[id])
+	}
+	return doc
+}
+
+/************************************
+* Function Name: spdxTagValue
+* Purpose: Write an SPDX document in the tag-value format. Files come before
+*          the packages so that they are not read as part of a package.
+* Parameters: doc spdxDocument
+* Output: []byte
+*************************************/
+func spdxTagValue(doc spdxDocument) []byte {
+	var b strings.Builder
+	tag := func(name, value string) {
+		if value != "" {
+			fmt.Fprintf(&b, "%s: %s\n", name, value)
+		}
+	}
+	text := func(name, value string) {
+		if value != "" {
+			fmt.Fprintf(&b, "%s: <text>%s</text>\n", name, value)
+		}
+	}
+
+	tag("SPDXVersion", doc.SPDXVersion)
+	tag("DataLicense", doc.DataLicense)
+	tag("SPDXID", doc.SPDXID)
+	tag("DocumentName", doc.Name)
+	tag("DocumentNamespace", doc.DocumentNamespace)
+	for _, c := range doc.CreationInfo.Creators {
+		tag("Creator", c)
+	}
+	tag("Created", doc.CreationInfo.Created)
+
+	for _, f := range doc.Files {
+		b.WriteString("\n")
+		tag("FileName", f.FileName)
+		tag("SPDXID", f.SPDXID)
+		for _, c := range f.Checksums {
+			tag("FileChecksum", c.Algorithm+": "+c.ChecksumValue)
+		}
+		tag("LicenseConcluded", f.LicenseConcluded)
+		tag("FileCopyrightText", f.CopyrightText)
+	}
+
+	for _, p := range doc.Packages {
+		b.WriteString("\n")
+		tag("PackageName", p.Name)
+		tag("SPDXID", p.SPDXID)
+		tag("PackageVersion", p.VersionInfo)
+		tag("PackageDownloadLocation", p.DownloadLocation)
+		tag("FilesAnalyzed", fmt.Sprint(p.FilesAnalyzed))
+		for _, c := range p.Checksums {
+			tag("PackageChecksum", c.Algorithm+": "+c.ChecksumValue)
+		}
+		text("PackageSourceInfo", p.SourceInfo)
+		tag("PackageLicenseConcluded", p.LicenseConcluded)
+		tag("PackageLicenseDeclared", p.LicenseDeclared)
+		tag("PackageCopyrightText", p.CopyrightText)
+		for _, r := range p.ExternalRefs {
+			tag("ExternalRef", r.ReferenceCategory+" "+r.ReferenceType+" "+r.ReferenceLocator)
+		}
+		tag("PrimaryPackagePurpose", p.PrimaryPackagePurpose)
+	}
+
+	for _, l := range doc.HasExtractedLicensingInfos {
+		b.WriteString("\n")
+		tag("LicenseID", l.LicenseID)
+		text("ExtractedText", l.ExtractedText)
+		tag("LicenseName", l.Name)
+	}
+
+	b.WriteString("\n")
+	for _, r := range doc.Relationships {
+		tag("Relationship", r.SPDXElementID+" "+r.RelationshipType+" "+r.RelatedSPDXElement)
+	}
+	return []byte(b.String())
+}
+
+/************************************
+* Function Name: spdxBOM
+* Purpose: Render an Analysis as an SPDX 2.3 document (JSON or tag-value).
+* Parameters: a Analysis, tagValue bool
+* Output: []byte, error
+*************************************/
+func spdxBOM(a Analysis, tagValue bool) ([]byte, error) {
+	doc := spdxDocumentFor(a)
+	if tagValue {
+		return spdxTagValue(doc), nil
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// sqliteDB is a read-only view of an SQLite 3 database file. It understands just
+// enough of the file format to list the rows of a table, which is all the rpm
+// database needs, without cgo or a driver.
+type sqliteDB struct {
+	data     []byte
+	pageSize int
+	usable   int // page size minus the reserved bytes at the end of each page
+}
+
+/************************************
+* Function Name: openSQLite
+* Purpose: Validate the header of an SQLite 3 database image held in memory.
+* Parameters: b []byte
+* Output: *sqliteDB, error
+*************************************/
+func openSQLite(b []byte) (*sqliteDB, error) {
+	if len(b) < 100 || string(b[:16]) != "SQLite format 3\x00" {
+		return nil, fmt.Errorf("not an SQLite 3 database")
+	}
+	pageSize := int(binary.BigEndian.Uint16(b[16:18]))
+	if pageSize == 1 {
+		pageSize = 65536
+	}
+	if pageSize < 512 || pageSize&(pageSize-1) != 0 {
+		return nil, fmt.Errorf("invalid SQLite page size %d", pageSize)
+	}
+	// the format requires at least 480 usable bytes per page
+	usable := pageSize - int(b[20])
+	if usable < 480 {
+		return nil, fmt.Errorf("invalid SQLite reserved space %d", b[20])
+	}
+	return &sqliteDB{data: b, pageSize: pageSize, usable: usable}, nil
+}
+
+/************************************
+* Function Name: page
+* Purpose: Return page n (1-based) of the database.
+* Parameters: n int
+* Output: []byte, error
+*************************************/
+func (db *sqliteDB) page(n int) ([]byte, error) {
+	off := (n - 1) * db.pageSize
+	if n < 1 || off+db.pageSize > len(db.data) {
+		return nil, fmt.Errorf("SQLite page %d out of range", n)
+	}
+	return db.data[off : off+db.pageSize], nil
+}
+
+/************************************
+* Function Name: sqliteVarint
+* Purpose: Decode an SQLite variable-length integer (1 to 9 bytes, big-endian,
+*          7 bits per byte except the ninth which holds 8).
+* Parameters: b []byte
+* Output: int64 (value), int (bytes consumed; 0 when b is truncated)
+*************************************/
+func sqliteVarint(b []byte) (int64, int) {
+	var v uint64
+	for i := 0; i < 9 && i < len(b); i++ {
+		if i == 8 {
+			return int64(v<<8 | uint64(b[i])), 9
+		}
+		v = v<<7 | uint64(b[i]&0x7f)
+		if b[i]&0x80 == 0 {
+			return int64(v), i + 1
+		}
+	}
+	return 0, 0
+}
+
+/************************************
+* Function Name: tableRows
+* Purpose: Walk the table b-tree rooted at a page and call fn with the decoded
+*          columns of every row, in rowid order.
+* Parameters: root int, fn func([]interface{})
+* Output: error
+*************************************/
+func (db *sqliteDB) tableRows(root int, fn func([]interface{})) error {
+	return db.walkTable(root, 0, map[int]bool{}, fn)
+}
+
+/************************************
+* Function Name: walkTable
+* Purpose: Recursive step of tableRows; depth and the visited pages guard
+*          against corrupt page loops.
+* Parameters: pgno int, depth int, seen map[int]bool, fn func([]interface{})
+* Output: error
+*************************************/
+func (db *sqliteDB) walkTable(pgno, depth int, seen map[int]bool, fn func([]interface{})) error {
+	if depth > 32 {
+		return fmt.Errorf("SQLite b-tree too deep")
+	}
+	if seen[pgno] {
+		return fmt.Errorf("SQLite page %d referenced twice", pgno)
+	}
+	seen[pgno] = true
+	p, err := db.page(pgno)
+	if err != nil {
+		return err
+	}
+	hdr := 0
+	if pgno == 1 {
+		hdr = 100 // the file header precedes the b-tree header on page 1
+	}
+	cells := int(binary.BigEndian.Uint16(p[hdr+3:]))
+	if hdr+12+2*cells > len(p) {
+		return fmt.Errorf("SQLite cell count out of range on page %d", pgno)
+	}
+	switch p[hdr] {
+	case 0x05: // interior table page: child pointers, then the right-most child
+		for i := 0; i < cells; i++ {
+			off := int(binary.BigEndian.Uint16(p[hdr+12+2*i:]))
+			if off+4 > len(p) {
+				return fmt.Errorf("SQLite cell out of range on page %d", pgno)
+			}
+			if err := db.walkTable(int(binary.BigEndian.Uint32(p[off:])), depth+1, seen, fn); err != nil {
+				return err
+			}
+		}
+		return db.walkTable(int(binary.BigEndian.Uint32(p[hdr+8:])), depth+1, seen, fn)
+	case 0x0d: // leaf table page: payload size, rowid, payload
+		for i := 0; i < cells; i++ {
+			off := int(binary.BigEndian.Uint16(p[hdr+8+2*i:]))
+			if off >= len(p) {
+				return fmt.Errorf("SQLite cell out of range on page %d", pgno)
+			}
+			size, n := sqliteVarint(p[off:])
+			off += n
+			_, m := sqliteVarint(p[off:])
+			off += m
+			if n == 0 || m == 0 {
+				return fmt.Errorf("truncated SQLite cell on page %d", pgno)
+			}
+			if size < 0 || size > int64(len(db.data)) {
+				return fmt.Errorf("SQLite payload size %d out of range on page %d", size, pgno)
+			}
+			payload, err := db.payload(p, off, int(size))
+			if err != nil {
+				return err
+			}
+			cols, err := sqliteColumns(payload)
+			if err != nil {
+				return fmt.Errorf("%v on page %d", err, pgno)
+			}
+			fn(cols)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unexpected SQLite page type %#x on page %d", p[hdr], pgno)
+	}
+}
+
+/************************************
+* Function Name: payload
+* Purpose: Assemble the payload of a table leaf cell, following its overflow
+*          page chain when it does not fit on the page.
+* Parameters: p []byte (page), off int (payload start), size int
+* Output: []byte, error
+*************************************/
+func (db *sqliteDB) payload(p []byte, off, size int) ([]byte, error) {
+	if size < 0 || size > len(db.data) {
+		return nil, fmt.Errorf("SQLite payload size %d out of range", size)
+	}
+	u := db.usable
+	local := size
+	if maxLocal := u - 35; size > maxLocal {
+		minLocal := (u-12)*32/255 - 23
+		local = minLocal + (size-minLocal)%(u-4)
+		if local > maxLocal {
+			local = minLocal
+		}
+	}
+	if off+local > len(p) || (local < size && off+local+4 > len(p)) {
+		return nil, fmt.Errorf("SQLite payload out of range")
+	}
+	out := append(make([]byte, 0, size), p[off:off+local]...)
+	if local == size {
+		return out, nil
+	}
+	next := int(binary.BigEndian.Uint32(p[off+local:]))
+	for len(out) < size && next != 0 {
+		op, err := db.page(next)
+		if err != nil {
+			return nil, err
+		}
+		next = int(binary.BigEndian.Uint32(op))
+		n := min(size-len(out), u-4)
+		out = append(out, op[4:4+n]...)
+	}
+	if len(out) < size {
+		return nil, fmt.Errorf("SQLite overflow chain ends early")
+	}
+	return out, nil
+}
+
+/************************************
+* Function Name: sqliteColumns
+* Purpose: Decode a record into its column values: int64 for integers, []byte
+*          for text and blobs, nil for NULL (and for floats, which are not needed).
+*          A header or column that does not fit the record is an error.
+* Parameters: rec []byte
+* Output: []interface{}, error
+*************************************/
+func sqliteColumns(rec []byte) ([]interface{}, error) {
+	hsize, n := sqliteVarint(rec)
+	if n == 0 || hsize < int64(n) || hsize > int64(len(rec)) {
+		return nil, fmt.Errorf("SQLite record header size %d out of range", hsize)
+	}
+	var types []int64
+	for pos := n; pos < int(hsize); {
+		t, k := sqliteVarint(rec[pos:int(hsize)])
+		if k == 0 {
+			return nil, fmt.Errorf("truncated SQLite record header")
+		}
+		if t < 0 || t == 10 || t == 11 {
+			return nil, fmt.Errorf("invalid SQLite serial type %d", t)
+		}
+		types = append(types, t)
+		pos += k
+	}
+	intSizes := []int64{0, 1, 2, 3, 4, 6, 8}
+	var cols []interface{}
+	body := rec[hsize:]
+	for _, t := range types {
+		var size int64
+		switch {
+		case t >= 1 && t <= 6:
+			size = intSizes[t]
+		case t == 7:
+			size = 8
+		case t >= 12:
+			size = (t - 12) / 2
+		}
+		if size > int64(len(body)) {
+			return nil, fmt.Errorf("SQLite column size %d out of range", size)
+		}
+		v := body[:size]
+		body = body[size:]
+		switch {
+		case t >= 1 && t <= 6:
+			var x int64
+			for _, c := range v {
+				x = x<<8 | int64(c)
+			}
+			// sign-extend
+			shift := uint(64 - 8*size)
+			cols = append(cols, x<<shift>>shift)
+		case t == 8:
+			cols = append(cols, int64(0))
+		case t == 9:
+			cols = append(cols, int64(1))
+		case t >= 12:
+			cols = append(cols, v)
+		default:
+			cols = append(cols, nil)
+		}
+	}
+	return cols, nil
+}
+
+/************************************
+* Function Name: tableRoot
+* Purpose: Look up the root page of a table in the sqlite_schema table.
+* Parameters: name string
+* Output: int, error
+*************************************/
+func (db *sqliteDB) tableRoot(name string) (int, error) {
+	root := 0
+	err := db.tableRows(1, func(cols []interface{}) {
+		if len(cols) < 4 {
+			return
+		}
+		typ, _ := cols[0].([]byte)
+		tbl, _ := cols[1].([]byte)
+		page, _ := cols[3].(int64)
+		if string(typ) == "table" && string(tbl) == name {
+			root = int(page)
+		}
+	})
+	if err != nil {
+		return 0, err
+	}
+	if root == 0 {
+		return 0, fmt.Errorf("SQLite table %s not found", name)
+	}
+	return root, nil
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+// sqliteTestRecord encodes a record of small integers and blobs, each short
+// enough for one-byte serial types.
+func sqliteTestRecord(cols ...interface{}) []byte {
+	var types, body []byte
+	for _, c := range cols {
+		switch v := c.(type) {
+		case int64:
+			types = append(types, 1)
+			body = append(body, byte(v))
+		case string:
+			types = append(types, byte(13+2*len(v)))
+			body = append(body, v...)
+		case []byte:
+			types = append(types, byte(12+2*len(v)))
+			body = append(body, v...)
+		}
+	}
+	return append(append([]byte{byte(len(types) + 1)}, types...), body...)
+}
+
+// sqliteTestPage writes a leaf table page holding one cell per record; hdr is
+// 100 on page 1, after the file header.
+func sqliteTestPage(p []byte, hdr int, records ...[]byte) {
+	p[hdr] = 0x0d
+	binary.BigEndian.PutUint16(p[hdr+3:], uint16(len(records)))
+	end := len(p)
+	for i, rec := range records {
+		cell := append([]byte{byte(len(rec)), byte(i + 1)}, rec...)
+		end -= len(cell)
+		copy(p[end:], cell)
+		binary.BigEndian.PutUint16(p[hdr+8+2*i:], uint16(end))
+	}
+	binary.BigEndian.PutUint16(p[hdr+5:], uint16(end))
+}
+
+// sqliteTestDB builds a two-page database: the schema on page 1 and a
+// Packages table with one row on page 2.
+func sqliteTestDB() []byte {
+	b := make([]byte, 1024)
+	copy(b, "SQLite format 3\x00")
+	binary.BigEndian.PutUint16(b[16:], 512)
+	b[18], b[19], b[21], b[22], b[23] = 1, 1, 64, 32, 32
+	sqliteTestPage(b[:512], 100, sqliteTestRecord("table", "Packages", "Packages", int64(2), "CREATE TABLE Packages(hnum INTEGER PRIMARY KEY,blob)"))
+	sqliteTestPage(b[512:], 0, sqliteTestRecord(int64(7), []byte("hello")))
+	return b
+}
+
+// sqliteTestCell returns the offset of the first cell on page 2.
+func sqliteTestCell(b []byte) int {
+	return 512 + int(binary.BigEndian.Uint16(b[512+8:]))
+}
+
+func TestSQLiteTableRows(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func([]byte) []byte
+		err    string // expected error substring, empty for success
+	}{
+		{"valid", func(b []byte) []byte { return b }, ""},
+		{"bad magic", func(b []byte) []byte { b[0] = 'X'; return b }, "not an SQLite 3 database"},
+		{"short header", func(b []byte) []byte { return b[:60] }, "not an SQLite 3 database"},
+		{"odd page size", func(b []byte) []byte { binary.BigEndian.PutUint16(b[16:], 700); return b }, "invalid SQLite page size"},
+		{"reserved space too large", func(b []byte) []byte { b[20] = 64; return b }, "invalid SQLite reserved space"},
+		{"truncated file", func(b []byte) []byte { return b[:700] }, "page 2 out of range"},
+		{"unknown page type", func(b []byte) []byte { b[512] = 0x42; return b }, "unexpected SQLite page type"},
+		{"cell count too large", func(b []byte) []byte { binary.BigEndian.PutUint16(b[512+3:], 400); return b }, "cell count out of range"},
+		{"cell pointer past page", func(b []byte) []byte { binary.BigEndian.PutUint16(b[512+8:], 600); return b }, "cell out of range"},
+		{"truncated cell varint", func(b []byte) []byte {
+			binary.BigEndian.PutUint16(b[512+8:], 511)
+			b[1023] = 0x81
+			return b
+		}, "truncated SQLite cell"},
+		{"negative payload size", func(b []byte) []byte {
+			c := sqliteTestCell(b)
+			copy(b[c-9:], []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 1})
+			binary.BigEndian.PutUint16(b[512+8:], uint16(c-9-512))
+			return b
+		}, "payload size"},
+		{"payload larger than file", func(b []byte) []byte {
+			c := sqliteTestCell(b)
+			copy(b[c-2:], []byte{0x81, 0x80, 0x80, 0x00, 1})
+			binary.BigEndian.PutUint16(b[512+8:], uint16(c-2-512))
+			return b
+		}, "payload size"},
+		{"missing overflow page", func(b []byte) []byte {
+			c := sqliteTestCell(b)
+			copy(b[c-1:], []byte{0x83, 0x70, 1})
+			binary.BigEndian.PutUint16(b[512+8:], uint16(c-1-512))
+			return b
+		}, "payload out of range"},
+		{"record header larger than record", func(b []byte) []byte { b[sqliteTestCell(b)+2] = 40; return b }, "record header size"},
+		{"empty record header", func(b []byte) []byte { b[sqliteTestCell(b)+2] = 0; return b }, "record header size"},
+		{"reserved serial type", func(b []byte) []byte { b[sqliteTestCell(b)+3] = 10; return b }, "invalid SQLite serial type"},
+		{"column past record", func(b []byte) []byte { b[sqliteTestCell(b)+4] = 12 + 2*50; return b }, "column size"},
+		{"interior page loop", func(b []byte) []byte {
+			b[512] = 0x05
+			binary.BigEndian.PutUint16(b[512+3:], 0)
+			binary.BigEndian.PutUint32(b[512+8:], 2)
+			return b
+		}, "referenced twice"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var rows [][]interface{}
+			err := func() error {
+				db, err := openSQLite(tt.mutate(sqliteTestDB()))
+				if err != nil {
+					return err
+				}
+				root, err := db.tableRoot("Packages")
+				if err != nil {
+					return err
+				}
+				return db.tableRows(root, func(cols []interface{}) { rows = append(rows, cols) })
+			}()
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(rows) != 1 || len(rows[0]) != 2 {
+				t.Fatalf("rows = %v, want one row of two columns", rows)
+			}
+			if n, _ := rows[0][0].(int64); n != 7 {
+				t.Errorf("column 0 = %v, want 7", rows[0][0])
+			}
+			if s, _ := rows[0][1].([]byte); string(s) != "hello" {
+				t.Errorf("column 1 = %q, want hello", rows[0][1])
+			}
+		})
+	}
+}
+
+func TestSQLiteColumns(t *testing.T) {
+	tests := []struct {
+		name string
+		rec  []byte
+		want int // number of columns, -1 for an error
+	}{
+		{"empty", nil, -1},
+		{"header only", []byte{1}, 0},
+		{"null and constants", []byte{4, 0, 8, 9}, 3},
+		{"negative header size", []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, -1},
+		{"truncated header varint", []byte{3, 0x81, 0x81}, -1},
+		{"truncated integer", []byte{2, 6, 1, 2}, -1},
+		{"huge text", []byte{9, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f}, -1},
+		{"negative serial type", []byte{10, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cols, err := sqliteColumns(tt.rec)
+			if tt.want < 0 {
+				if err == nil {
+					t.Fatalf("cols = %v, want an error", cols)
+				}
+				return
+			}
+			if err != nil || len(cols) != tt.want {
+				t.Fatalf("cols = %v, err = %v, want %d columns", cols, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,352 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+/************************************
+* Minimal YAML reader
+*
+* Manifests such as pubspec.yaml, environment.yml or GitHub workflows only use
+* a small part of YAML. This reader handles block mappings and sequences,
+* quoted and plain scalars, simple flow collections ([a, b] / {a: b}), block
+* scalars (| and >), comments and multiple documents. Every scalar is returned
+* as a string so versions such as 1.10 keep their exact spelling. Anchors are
+* dropped and aliases are returned verbatim.
+*
+* Not supported: alias resolution and merge keys (<<), tags other than a
+* leading !! (which is dropped), complex keys (? key), flow collections or
+* quoted scalars that span several lines, multi-line plain scalars (the
+* continuation lines are skipped) and block scalar chomping (every block
+* scalar is trimmed). Tabs are not accepted as indentation.
+*
+* Results are built from map[string]interface{}, []interface{} and string.
+*************************************/
+
+type yamlParser struct {
+	lines []string
+	pos   int
+}
+
+/************************************
+* Function Name: parseYAMLDocuments
+* Purpose: Parse every document in a YAML stream (separated by ---).
+* Parameters: s string
+* Output: []interface{} (one entry per non-empty document)
+*************************************/
+func parseYAMLDocuments(s string) []interface{} {
+	var docs []interface{}
+	var current []string
+	flush := func() {
+		p := &yamlParser{lines: current}
+		if v := p.parseNode(0); v != nil {
+			docs = append(docs, v)
+		}
+		current = nil
+	}
+	for _, raw := range strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(raw)
+		if raw == "---" || strings.HasPrefix(raw, "--- ") || raw == "..." {
+			flush()
+			if rest := strings.TrimSpace(strings.TrimPrefix(trimmed, "---")); rest != "" && rest != "..." {
+				current = append(current, rest)
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "%") && len(current) == 0 {
+			continue // directives such as %YAML 1.2
+		}
+		current = append(current, strings.TrimRight(raw, " \t"))
+	}
+	flush()
+	return docs
+}
+
+/************************************
+* Function Name: parseYAML
+* Purpose: Parse the first document of a YAML file.
+* Parameters: s string
+* Output: interface{} (nil when empty)
+*************************************/
+func parseYAML(s string) interface{} {
+	docs := parseYAMLDocuments(s)
+	if len(docs) == 0 {
+		return nil
+	}
+	return docs[0]
+}
+
+func yamlIndent(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+// stripYAMLComment removes a trailing # comment that is outside quotes.
+func stripYAMLComment(line string) string {
+	inSingle, inDouble := false, false
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case c == '\'' && !inDouble:
+			inSingle = !inSingle
+		case c == '"' && !inSingle:
+			if i == 0 || line[i-1] != '\\' {
+				inDouble = !inDouble
+			}
+		case c == '#' && !inSingle && !inDouble:
+			if i == 0 || line[i-1] == ' ' || line[i-1] == '\t' {
+				return strings.TrimRight(line[:i], " \t")
+			}
+		}
+	}
+	return line
+}
+
+// current returns the next meaningful line (skipping blanks and comments) and its indent.
+func (p *yamlParser) current() (string, int, bool) {
+	for p.pos < len(p.lines) {
+		line := stripYAMLComment(p.lines[p.pos])
+		if strings.TrimSpace(line) == "" {
+			p.pos++
+			continue
+		}
+		return line, yamlIndent(line), true
+	}
+	return "", 0, false
+}
+
+func isYAMLSeqItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+func (p *yamlParser) parseNode(minIndent int) interface{} {
+	line, ind, ok := p.current()
+	if !ok || ind < minIndent {
+		return nil
+	}
+	if isYAMLSeqItem(strings.TrimSpace(line)) {
+		return p.parseSeq(ind)
+	}
+	return p.parseMap(ind)
+}
+
+func (p *yamlParser) parseSeq(ind int) []interface{} {
+	list := []interface{}{}
+	for {
+		line, li, ok := p.current()
+		if !ok || li != ind || !isYAMLSeqItem(strings.TrimSpace(line)) {
+			return list
+		}
+		content := strings.TrimSpace(strings.TrimSpace(line)[1:])
+		if content == "" {
+			p.pos++
+			list = append(list, p.parseNode(ind+1))
+			continue
+		}
+		if _, _, isEntry := splitYAMLKey(content); isEntry || isYAMLSeqItem(content) {
+			// "- key: value" starts a mapping (or nested sequence) at the content column
+			col := len(line) - len(strings.TrimLeft(line[ind+1:], " "))
+			p.lines[p.pos] = strings.Repeat(" ", col) + content
+			list = append(list, p.parseNode(col))
+			continue
+		}
+		p.pos++
+		list = append(list, parseYAMLScalar(content))
+	}
+}
+
+func (p *yamlParser) parseMap(ind int) map[string]interface{} {
+	m := map[string]interface{}{}
+	for {
+		line, li, ok := p.current()
+		if !ok || li < ind {
+			return m
+		}
+		if li > ind {
+			p.pos++ // stray continuation line
+			continue
+		}
+		text := strings.TrimSpace(line)
+		if isYAMLSeqItem(text) {
+			return m
+		}
+		key, value, isEntry := splitYAMLKey(text)
+		if !isEntry {
+			p.pos++
+			continue
+		}
+		p.pos++
+		value = stripYAMLAnchor(value)
+		switch {
+		case value == "":
+			if _, next, ok := p.current(); ok && next > ind {
+				m[key] = p.parseNode(next)
+			} else if nl, next, ok := p.current(); ok && next == ind && isYAMLSeqItem(strings.TrimSpace(nl)) {
+				m[key] = p.parseSeq(ind)
+			} else {
+				m[key] = nil
+			}
+		case strings.HasPrefix(value, "|") || strings.HasPrefix(value, ">"):
+			m[key] = p.blockScalar(ind, value[0] == '>')
+		default:
+			m[key] = parseYAMLScalar(value)
+		}
+	}
+}
+
+func (p *yamlParser) blockScalar(ind int, folded bool) string {
+	var parts []string
+	blockIndent := -1
+	for p.pos < len(p.lines) {
+		raw := p.lines[p.pos]
+		if strings.TrimSpace(raw) == "" {
+			parts = append(parts, "")
+			p.pos++
+			continue
+		}
+		li := yamlIndent(raw)
+		if li <= ind {
+			break
+		}
+		if blockIndent == -1 {
+			blockIndent = li
+		}
+		if li < blockIndent {
+			blockIndent = li
+		}
+		parts = append(parts, raw[blockIndent:])
+		p.pos++
+	}
+	sep := "\n"
+	if folded {
+		sep = " "
+	}
+	return strings.TrimSpace(strings.Join(parts, sep))
+}
+
+// splitYAMLKey splits "key: value" at the first ':' that is followed by a space
+// or ends the line and lies outside quotes and flow collections.
+func splitYAMLKey(text string) (string, string, bool) {
+	inSingle, inDouble, depth := false, false, 0
+	for i := 0; i < len(text); i++ {
+		switch c := text[i]; {
+		case c == '\'' && !inDouble:
+			inSingle = !inSingle
+		case c == '"' && !inSingle:
+			inDouble = !inDouble
+		case inSingle || inDouble:
+		case c == '[' || c == '{':
+			depth++
+		case c == ']' || c == '}':
+			depth--
+		case c == ':' && depth == 0 && (i == len(text)-1 || text[i+1] == ' ' || text[i+1] == '\t'):
+			key := yamlUnquote(strings.TrimSpace(text[:i]))
+			return key, strings.TrimSpace(text[i+1:]), i > 0
+		}
+	}
+	return "", "", false
+}
+
+func stripYAMLAnchor(value string) string {
+	if strings.HasPrefix(value, "&") {
+		if idx := strings.IndexAny(value, " \t"); idx != -1 {
+			return strings.TrimSpace(value[idx:])
+		}
+		return ""
+	}
+	if strings.HasPrefix(value, "!!") {
+		if idx := strings.IndexAny(value, " \t"); idx != -1 {
+			return strings.TrimSpace(value[idx:])
+		}
+	}
+	return value
+}
+
+func yamlUnquote(s string) string {
+	if len(s) >= 2 {
+		switch {
+		case s[0] == '"' && s[len(s)-1] == '"':
+			if u, err := strconv.Unquote(s); err == nil {
+				return u
+			}
+			return s[1 : len(s)-1]
+		case s[0] == '\'' && s[len(s)-1] == '\'':
+			return strings.ReplaceAll(s[1:len(s)-1], "''", "'")
+		}
+	}
+	return s
+}
+
+// splitYAMLFlow splits the inside of a flow collection on top-level commas.
+func splitYAMLFlow(s string) []string {
+	var items []string
+	inSingle, inDouble, depth, start := false, false, 0, 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\'' && !inDouble:
+			inSingle = !inSingle
+		case c == '"' && !inSingle:
+			inDouble = !inDouble
+		case inSingle || inDouble:
+		case c == '[' || c == '{':
+			depth++
+		case c == ']' || c == '}':
+			depth--
+		case c == ',' && depth == 0:
+			items = append(items, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+	if last := strings.TrimSpace(s[start:]); last != "" {
+		items = append(items, last)
+	}
+	return items
+}
+
+func parseYAMLScalar(s string) interface{} {
+	s = strings.TrimSpace(stripYAMLAnchor(s))
+	switch {
+	case strings.HasPrefix(s, "[") && strings.HasSuffix(s, "]"):
+		list := []interface{}{}
+		for _, item := range splitYAMLFlow(s[1 : len(s)-1]) {
+			list = append(list, parseYAMLScalar(item))
+		}
+		return list
+	case strings.HasPrefix(s, "{") && strings.HasSuffix(s, "}"):
+		m := map[string]interface{}{}
+		for _, item := range splitYAMLFlow(s[1 : len(s)-1]) {
+			if k, v, ok := splitYAMLKey(item); ok {
+				m[k] = parseYAMLScalar(v)
+			} else if k := strings.TrimSuffix(item, ":"); k != "" {
+				m[yamlUnquote(k)] = nil
+			}
+		}
+		return m
+	case s == "~" || s == "null":
+		return nil
+	}
+	return yamlUnquote(s)
+}
+
+/************************************
+* YAML value helpers: tolerant accessors returning zero values on type mismatch.
+*************************************/
+func yamlMap(v interface{}) map[string]interface{} {
+	if m, ok := v.(map[string]interface{}); ok {
+		return m
+	}
+	return nil
+}
+
+func yamlList(v interface{}) []interface{} {
+	if l, ok := v.([]interface{}); ok {
+		return l
+	}
+	return nil
+}
+
+func yamlString(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return ""
+}
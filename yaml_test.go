@@ -0,0 +1,61 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseYAML(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want interface{}
+	}{
+		{"block mapping", "name: app\nversion: 1.10\n", map[string]interface{}{"name": "app", "version": "1.10"}},
+		{"nested mapping", "dependencies:\n  http: ^1.1.0\n  path: any\n",
+			map[string]interface{}{"dependencies": map[string]interface{}{"http": "^1.1.0", "path": "any"}}},
+		{"block sequence", "deps:\n  - numpy=1.26\n  - pandas\n",
+			map[string]interface{}{"deps": []interface{}{"numpy=1.26", "pandas"}}},
+		{"sequence at key indent", "deps:\n- a\n- b\n",
+			map[string]interface{}{"deps": []interface{}{"a", "b"}}},
+		{"flow sequence", "branches: [main, 'release/*', \"v1\"]\n",
+			map[string]interface{}{"branches": []interface{}{"main", "release/*", "v1"}}},
+		{"flow mapping", "with: {node-version: 20, cache: npm}\n",
+			map[string]interface{}{"with": map[string]interface{}{"node-version": "20", "cache": "npm"}}},
+		{"quoted scalars", "a: \"x: y # z\"\nb: 'it''s'\n\"c d\": \"\\t\"\n",
+			map[string]interface{}{"a": "x: y # z", "b": "it's", "c d": "\t"}},
+		{"comments", "# header\nname: app # trailing\n  # indented\nurl: http://host/#frag\n",
+			map[string]interface{}{"name": "app", "url": "http://host/#frag"}},
+		{"null values", "a:\nb: ~\nc: null\n", map[string]interface{}{"a": nil, "b": nil, "c": nil}},
+		{"nested map in list", "dependencies:\n  - python=3.11\n  - pip:\n      - requests==2.31.0\n      - flask\n  - numpy\n",
+			map[string]interface{}{"dependencies": []interface{}{
+				"python=3.11",
+				map[string]interface{}{"pip": []interface{}{"requests==2.31.0", "flask"}},
+				"numpy",
+			}}},
+		{"list of mappings", "steps:\n  - uses: actions/checkout@v4\n    with:\n      fetch-depth: 0\n  - run: make\n",
+			map[string]interface{}{"steps": []interface{}{
+				map[string]interface{}{"uses": "actions/checkout@v4", "with": map[string]interface{}{"fetch-depth": "0"}},
+				map[string]interface{}{"run": "make"},
+			}}},
+		{"literal block scalar", "run: |\n  npm ci\n  npm test\nname: ci\n",
+			map[string]interface{}{"run": "npm ci\nnpm test", "name": "ci"}},
+		{"folded block scalar", "desc: >\n  one\n  two\n", map[string]interface{}{"desc": "one two"}},
+		{"anchor dropped", "base: &base\n  a: 1\n", map[string]interface{}{"base": map[string]interface{}{"a": "1"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseYAML(tt.in); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseYAML() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseYAMLDocuments(t *testing.T) {
+	docs := parseYAMLDocuments("---\na: 1\n---\n# empty\n---\nb: 2\n")
+	want := []interface{}{map[string]interface{}{"a": "1"}, map[string]interface{}{"b": "2"}}
+	if !reflect.DeepEqual(docs, want) {
+		t.Errorf("parseYAMLDocuments() = %#v, want %#v", docs, want)
+	}
+}